@@ -0,0 +1,81 @@
+// Package sentryanalysis reports BeforeSend functions that drop every event.
+package sentryanalysis
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports BeforeSend function literals whose return statements all return nil.
+// Run it with go vet -vettool, see the beforesendcheck command.
+var Analyzer = &analysis.Analyzer{
+	Name:     "beforesend",
+	Doc:      "report BeforeSend functions that always return nil, dropping all events",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+const message = "BeforeSend function always returns nil, all events will be dropped"
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{(*ast.KeyValueExpr)(nil), (*ast.AssignStmt)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.KeyValueExpr:
+			// sentry.ClientOptions{BeforeSend: func...}
+			if key, ok := n.Key.(*ast.Ident); ok && key.Name == "BeforeSend" {
+				check(pass, n.Value)
+			}
+		case *ast.AssignStmt:
+			// options.BeforeSend = func...
+			if len(n.Lhs) != len(n.Rhs) {
+				return
+			}
+			for i, lhs := range n.Lhs {
+				if sel, ok := lhs.(*ast.SelectorExpr); ok && sel.Sel.Name == "BeforeSend" {
+					check(pass, n.Rhs[i])
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+func check(pass *analysis.Pass, expr ast.Expr) {
+	lit, ok := expr.(*ast.FuncLit)
+	if !ok || lit.Type.Results == nil || len(lit.Type.Results.List) != 1 {
+		return
+	}
+	if alwaysReturnsNil(pass, lit.Body) {
+		pass.Reportf(lit.Pos(), message)
+	}
+}
+
+// alwaysReturnsNil reports whether the body has return statements and all of them return nil.
+// Return statements of nested function literals are not those of the body.
+func alwaysReturnsNil(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	returns, allNil := 0, true
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			returns++
+			if len(n.Results) != 1 || !isNil(pass, n.Results[0]) {
+				allNil = false
+			}
+		}
+		return true
+	})
+	return returns > 0 && allNil
+}
+
+func isNil(pass *analysis.Pass, expr ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[expr]
+	return ok && tv.IsNil() && types.Identical(tv.Type, types.Typ[types.UntypedNil])
+}
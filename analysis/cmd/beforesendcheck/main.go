@@ -0,0 +1,13 @@
+// Command beforesendcheck runs the BeforeSend analyzer with go vet:
+//
+//	go vet -vettool=$(which beforesendcheck) ./...
+package main
+
+import (
+	sentryanalysis "github.com/digitalmint/go-sentry-middleware/analysis"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(sentryanalysis.Analyzer)
+}
@@ -0,0 +1,40 @@
+package a
+
+import "github.com/getsentry/sentry-go"
+
+func dropAll() sentry.ClientOptions {
+	return sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event { // want "BeforeSend function always returns nil, all events will be dropped"
+			event.Message = "redacted"
+			return nil
+		},
+	}
+}
+
+func dropAllBranches(options *sentry.ClientOptions) {
+	options.BeforeSend = func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event { // want "BeforeSend function always returns nil, all events will be dropped"
+		if event.Message == "" {
+			return nil
+		}
+		return nil
+	}
+}
+
+func filter() sentry.ClientOptions {
+	return sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			if event.Message == "health check" {
+				return nil
+			}
+			return event
+		},
+	}
+}
+
+func nested(options *sentry.ClientOptions) {
+	options.BeforeSend = func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		redact := func() *sentry.Event { return nil }
+		redact()
+		return event
+	}
+}
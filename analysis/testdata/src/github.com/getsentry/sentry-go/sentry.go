@@ -0,0 +1,12 @@
+// Package sentry is a stub of the sentry-go types used by the analyzer tests.
+package sentry
+
+type Event struct {
+	Message string
+}
+
+type EventHint struct{}
+
+type ClientOptions struct {
+	BeforeSend func(event *Event, hint *EventHint) *Event
+}
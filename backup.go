@@ -0,0 +1,175 @@
+package sentry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/digitalmint/go-sentry-middleware/internal/envelope"
+	"github.com/getsentry/sentry-go"
+)
+
+// LocalBackupTransport is an http.RoundTripper that appends the events Sentry did not accept to a local file,
+// one JSON event per line with DSNs redacted, so that they can be sent again with ReplayBackupFile.
+// An event is backed up when the request fails or the response is a 5xx.
+type LocalBackupTransport struct {
+	RT   http.RoundTripper
+	Path string
+	// MaxSizeBytes rotates the file to Path + ".1" before it grows past this size, 0 means no limit
+	MaxSizeBytes int64
+	mu           *sync.Mutex
+}
+
+func NewLocalBackupTransport(rt http.RoundTripper, path string, maxSizeBytes int64) LocalBackupTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return LocalBackupTransport{RT: rt, Path: path, MaxSizeBytes: maxSizeBytes, mu: &sync.Mutex{}}
+}
+
+func (lbt LocalBackupTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return lbt.RT.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := lbt.RT.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 500 {
+		if backupErr := lbt.backup(body); backupErr != nil {
+			slog.ErrorContext(req.Context(), "Sentry event backup failure", "error", backupErr)
+		}
+	}
+	return resp, err
+}
+
+func (lbt LocalBackupTransport) backup(body []byte) error {
+	payload, err := eventPayload(body)
+	if err != nil {
+		return err
+	}
+	var line bytes.Buffer
+	if err := json.Compact(&line, RedactDSN(payload)); err != nil {
+		return err
+	}
+	line.WriteByte('\n')
+
+	lbt.mu.Lock()
+	defer lbt.mu.Unlock()
+	if lbt.MaxSizeBytes > 0 {
+		if info, err := os.Stat(lbt.Path); err == nil && info.Size() > 0 && info.Size()+int64(line.Len()) > lbt.MaxSizeBytes {
+			if err := os.Rename(lbt.Path, lbt.Path+".1"); err != nil {
+				return err
+			}
+		}
+	}
+	return appendToFile(lbt.Path, line.Bytes())
+}
+
+// eventPayload returns the JSON of the event of a store request or of an envelope.
+func eventPayload(body []byte) ([]byte, error) {
+	if !envelope.IsEnvelope(body) {
+		return body, nil
+	}
+	items, err := envelope.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.Type == "event" || item.Type == "transaction" {
+			return item.Payload, nil
+		}
+	}
+	return nil, errors.New("no event in the envelope")
+}
+
+func appendToFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReplayBackupFile captures the events of a LocalBackupTransport file and of its rotated Path + ".1" with the hub,
+// oldest first, and removes the files.
+// Events that cannot be decoded or that the hub drops are counted as failed and written back to the file.
+// A replay interrupted by a crash is resumed by the next call: its events may be sent twice but are not lost.
+// Sent events are queued by the client: flush the hub to wait for them.
+func ReplayBackupFile(path string, hub *sentry.Hub) (sent, failed int, err error) {
+	// events backed up while replaying go to a new file, the files to replay are appended to
+	// path + ".replaying" so that the leftover of an interrupted replay is never overwritten
+	replaying := path + ".replaying"
+	moving := replaying + ".next"
+	for _, source := range []string{moving, path + ".1", path} {
+		if source != moving {
+			if err := os.Rename(source, moving); errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return 0, 0, err
+			}
+		}
+		if err := appendFileTo(replaying, moving); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, 0, err
+		}
+	}
+	f, err := os.Open(replaying)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var failedLines bytes.Buffer
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		event := sentry.Event{}
+		if json.Unmarshal(line, &event) != nil || hub.CaptureEvent(&event) == nil {
+			failed++
+			failedLines.Write(line)
+			failedLines.WriteByte('\n')
+			continue
+		}
+		sent++
+	}
+	if err := scanner.Err(); err != nil {
+		return sent, failed, fmt.Errorf("reading %s: %w", replaying, err)
+	}
+	if failedLines.Len() > 0 {
+		if err := appendToFile(path, failedLines.Bytes()); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, os.Remove(replaying)
+}
+
+// appendFileTo appends the content of src to dst and removes src.
+func appendFileTo(dst, src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := appendToFile(dst, data); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
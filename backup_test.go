@@ -0,0 +1,142 @@
+package sentry
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func newBackupClient(t *testing.T, backup LocalBackupTransport) *sentry.Client {
+	t.Helper()
+	transport := sentry.NewHTTPSyncTransport()
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:           "https://key@o1.ingest.sentry.io/1",
+		Transport:     transport,
+		HTTPTransport: backup,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+var unavailable = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+	io.Copy(io.Discard, r.Body)
+	return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+})
+
+func TestLocalBackupTransportReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentry.ndjson")
+	client := newBackupClient(t, NewLocalBackupTransport(unavailable, path, 0))
+	client.CaptureMessage("sending to https://key@o1.ingest.sentry.io/1 failed", nil, nil)
+	client.CaptureMessage("second", nil, nil)
+
+	backup, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(backup)), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "sentry.io") {
+		t.Fatalf("unexpected backup %s", backup)
+	}
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	sent, failed, err := ReplayBackupFile(path, hub)
+	if err != nil || sent != 2 || failed != 0 {
+		t.Fatalf("unexpected replay %d %d %v", sent, failed, err)
+	}
+	if transport.Events()[0].Message != "sending to REDACTED failed" || transport.Events()[1].Message != "second" {
+		t.Errorf("unexpected events %q %q", transport.Events()[0].Message, transport.Events()[1].Message)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the backup file to be removed %v", err)
+	}
+}
+
+func TestLocalBackupTransportRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentry.ndjson")
+	client := newBackupClient(t, NewLocalBackupTransport(unavailable, path, 200))
+	for i := 0; i < 3; i++ {
+		client.CaptureMessage(strings.Repeat("x", 100), nil, nil)
+	}
+	rotated, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(rotated), "\n") != 1 || strings.Count(string(current), "\n") != 1 {
+		t.Errorf("expected one event per file\n%s\n%s", rotated, current)
+	}
+}
+
+func TestReplayBackupFileRotated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentry.ndjson")
+	client := newBackupClient(t, NewLocalBackupTransport(unavailable, path, 200))
+	client.CaptureMessage("rotated "+strings.Repeat("x", 100), nil, nil)
+	client.CaptureMessage("current "+strings.Repeat("x", 100), nil, nil)
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	sent, failed, err := ReplayBackupFile(path, hub)
+	if err != nil || sent != 2 || failed != 0 {
+		t.Fatalf("unexpected replay %d %d %v", sent, failed, err)
+	}
+	if !strings.HasPrefix(transport.Events()[0].Message, "rotated") || !strings.HasPrefix(transport.Events()[1].Message, "current") {
+		t.Errorf("expected the rotated file to be replayed first %q %q", transport.Events()[0].Message, transport.Events()[1].Message)
+	}
+	for _, p := range []string{path, path + ".1", path + ".replaying"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed %v", p, err)
+		}
+	}
+}
+
+func TestReplayBackupFileAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentry.ndjson")
+	client := newBackupClient(t, NewLocalBackupTransport(unavailable, path, 0))
+	client.CaptureMessage("interrupted", nil, nil)
+	// a replay that crashed after moving the file
+	if err := os.Rename(path, path+".replaying"); err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("after the crash", nil, nil)
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	sent, failed, err := ReplayBackupFile(path, hub)
+	if err != nil || sent != 2 || failed != 0 {
+		t.Fatalf("unexpected replay %d %d %v", sent, failed, err)
+	}
+	if transport.Events()[0].Message != "interrupted" || transport.Events()[1].Message != "after the crash" {
+		t.Errorf("unexpected events %q %q", transport.Events()[0].Message, transport.Events()[1].Message)
+	}
+	if _, err := os.Stat(path + ".replaying"); !os.IsNotExist(err) {
+		t.Errorf("expected the replay file to be removed %v", err)
+	}
+}
+
+func TestReplayBackupFileMissing(t *testing.T) {
+	hub, _ := sentrytest.NewRecordingHub(t)
+	if _, _, err := ReplayBackupFile(filepath.Join(t.TempDir(), "sentry.ndjson"), hub); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestLocalBackupTransportSuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentry.ndjson")
+	accepted := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: r}, nil
+	})
+	client := newBackupClient(t, NewLocalBackupTransport(accepted, path, 0))
+	client.CaptureMessage("sent", nil, nil)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no backup %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package sentry
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// InjectBaggageHeader returns a copy of req with the dynamic sampling context of the hub in the W3C baggage header.
+// Entries already on the request that are not set by Sentry are kept.
+func InjectBaggageHeader(req *http.Request, hub *sentry.Hub) *http.Request {
+	return injectBaggage(req, hub.GetBaggage())
+}
+
+func injectBaggage(req *http.Request, baggage string) *http.Request {
+	entries := ExtractBaggageFromRequest(req)
+	for key, value := range ParseBaggage(baggage) {
+		entries[key] = value
+	}
+	req = req.Clone(req.Context())
+	if len(entries) == 0 {
+		req.Header.Del(sentry.SentryBaggageHeader)
+		return req
+	}
+	req.Header.Set(sentry.SentryBaggageHeader, SerializeBaggage(entries))
+	return req
+}
+
+// ExtractBaggageFromRequest parses the W3C baggage header of the request.
+func ExtractBaggageFromRequest(req *http.Request) map[string]string {
+	entries := map[string]string{}
+	for _, header := range req.Header.Values(sentry.SentryBaggageHeader) {
+		for key, value := range ParseBaggage(header) {
+			entries[key] = value
+		}
+	}
+	return entries
+}
+
+// ParseBaggage parses a W3C baggage header value. Member properties are dropped and invalid members are skipped.
+// See: https://www.w3.org/TR/baggage/
+func ParseBaggage(header string) map[string]string {
+	entries := map[string]string{}
+	for _, member := range strings.Split(header, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		key, value, found := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		entries[key] = unescaped
+	}
+	return entries
+}
+
+// SerializeBaggage formats entries as a W3C baggage header value, sorted by key.
+func SerializeBaggage(entries map[string]string) string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	members := make([]string, 0, len(keys))
+	for _, key := range keys {
+		members = append(members, key+"="+url.PathEscape(entries[key]))
+	}
+	return strings.Join(members, ",")
+}
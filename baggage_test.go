@@ -0,0 +1,54 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestBaggageRoundTrip(t *testing.T) {
+	entries := map[string]string{
+		"sentry-trace_id":    "771a43a4192642f0b136d5159a501700",
+		"sentry-transaction": "GET /users/{id}",
+		"tenant":             "a=b,c;d",
+	}
+	parsed := ParseBaggage(SerializeBaggage(entries))
+	if len(parsed) != len(entries) {
+		t.Fatalf("unexpected %v", parsed)
+	}
+	for key, value := range entries {
+		if parsed[key] != value {
+			t.Errorf("%s: got %q want %q", key, parsed[key], value)
+		}
+	}
+	if parsed := ParseBaggage("k1=v1;prop=1, invalid ,=novalue,k2 = v2"); len(parsed) != 2 || parsed["k1"] != "v1" || parsed["k2"] != "v2" {
+		t.Errorf("unexpected %v", parsed)
+	}
+}
+
+func TestInjectBaggageHeader(t *testing.T) {
+	hub, _ := newTracingHub(t)
+	tx := sentry.StartTransaction(sentry.SetHubOnContext(context.Background(), hub), "job")
+	defer tx.Finish()
+	hub.Scope().SetPropagationContext(sentry.NewPropagationContext())
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("baggage", "vendor=kept")
+	injected := InjectBaggageHeader(req, hub)
+
+	entries := ExtractBaggageFromRequest(injected)
+	if entries["vendor"] != "kept" {
+		t.Errorf("third party baggage lost %v", entries)
+	}
+	if entries["sentry-trace_id"] == "" {
+		t.Errorf("expected the sentry trace id %v", entries)
+	}
+	if req.Header.Get("baggage") != "vendor=kept" {
+		t.Errorf("the original request was modified")
+	}
+}
@@ -0,0 +1,51 @@
+package sentry
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type BatchCaptureOpts struct {
+	// MaxBatchSize is the number of events sent before waiting for them to be delivered, 0 means no limit
+	MaxBatchSize int
+	// FlushTimeout bounds the wait after each batch, defaults to 5 seconds
+	FlushTimeout time.Duration
+	// Fingerprinter groups the events, nil keeps the Sentry grouping
+	Fingerprinter Fingerprint
+	// Tags are set on every event of the batch
+	Tags map[string]string
+}
+
+// BatchCapture captures many errors, for example of a background job, in batches of MaxBatchSize.
+// A Sentry envelope carries a single event, so a batch is the events queued on the transport
+// before flushing it, which bounds the memory used by a large loop.
+// The events go through the BeforeSend of the client. The event IDs are in the order of errs,
+// an empty ID means the event was dropped.
+func BatchCapture(hub *sentry.Hub, errs []error, opts BatchCaptureOpts) []sentry.EventID {
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = 5 * time.Second
+	}
+	ids := make([]sentry.EventID, len(errs))
+	for i, err := range errs {
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetTags(opts.Tags)
+			if opts.Fingerprinter != nil {
+				fingerprint, fpErr := opts.Fingerprinter(err, nil)
+				if fpErr != nil {
+					DefaultFingerprintErrorHandler(fpErr)
+				} else if fingerprint != nil {
+					scope.SetFingerprint(fingerprint)
+				}
+			}
+			if id := hub.CaptureException(err); id != nil {
+				ids[i] = *id
+			}
+		})
+		if sent := i + 1; opts.MaxBatchSize > 0 && sent%opts.MaxBatchSize == 0 && sent < len(errs) {
+			hub.Flush(opts.FlushTimeout)
+		}
+	}
+	hub.Flush(opts.FlushTimeout)
+	return ids
+}
@@ -0,0 +1,73 @@
+package sentry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+type flushCountingTransport struct {
+	sentrytest.EventRecorder
+	// batches are the number of events sent before each flush
+	batches []int
+}
+
+func (t *flushCountingTransport) Flush(_ time.Duration) bool {
+	sent := len(t.Events())
+	for _, batch := range t.batches {
+		sent -= batch
+	}
+	t.batches = append(t.batches, sent)
+	return true
+}
+
+func TestBatchCapture(t *testing.T) {
+	transport := &flushCountingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "https://key@o1.ingest.sentry.io/1",
+		Transport: transport,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			if event.Exception[0].Value == "error 3" {
+				return nil
+			}
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	errs := make([]error, 7)
+	for i := range errs {
+		errs[i] = fmt.Errorf("error %d", i)
+	}
+	ids := BatchCapture(hub, errs, BatchCaptureOpts{
+		MaxBatchSize: 3,
+		Fingerprinter: func(err error, _ []string) ([]string, error) {
+			return []string{"job", err.Error()}, nil
+		},
+		Tags: map[string]string{"job": "import"},
+	})
+
+	if fmt.Sprint(transport.batches) != "[3 2 1]" {
+		t.Errorf("unexpected batches %v", transport.batches)
+	}
+	seen := map[sentry.EventID]bool{}
+	for i, id := range ids {
+		if (id == "") != (i == 3) || seen[id] {
+			t.Errorf("unexpected id %d %q", i, id)
+		}
+		seen[id] = true
+	}
+	event := transport.Events()[0]
+	if event.Tags["job"] != "import" || fmt.Sprint(event.Fingerprint) != "[job error 0]" {
+		t.Errorf("unexpected event %v %v", event.Tags, event.Fingerprint)
+	}
+	if tags := sentrytest.CapturedScope(hub).Tags(); len(tags) != 0 {
+		t.Errorf("the batch tags leaked into the hub scope %v", tags)
+	}
+}
@@ -0,0 +1,129 @@
+package sentry
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// BeforeSendFn is the signature of sentry.ClientOptions.BeforeSend
+type BeforeSendFn func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event
+
+// ChainBeforeSend runs each function in order, passing on the event returned by the previous one.
+// If a function drops the event by returning nil, the rest are not run.
+func ChainBeforeSend(fns ...BeforeSendFn) BeforeSendFn {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if event = fn(event, hint); event == nil {
+				return nil
+			}
+		}
+		return event
+	}
+}
+
+// DefaultPIIPatterns match email addresses, IPv6 and IPv4 addresses, and US phone numbers.
+// The IP addresses are matched as IPAddressRedactor does, with the regexes of IPv6Pattern and IPv4Pattern.
+var DefaultPIIPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	IPv6Pattern.Regex,
+	IPv4Pattern.Regex,
+	// +1 and a number with optional separators, or an optional 1 country code, an area code in parentheses
+	// or followed by a separator, and a number with a separator: plain runs of digits such as IDs do not match
+	regexp.MustCompile(`\+1[ .\-]?\(?[2-9][0-9]{2}\)?[ .\-]?[0-9]{3}[ .\-]?[0-9]{4}\b|` +
+		`(?:\b1[ .\-])?(?:\(\b[2-9][0-9]{2}\)[ .\-]?|\b[2-9][0-9]{2}[ .\-])[0-9]{3}[ .\-][0-9]{4}\b`),
+}
+
+// PIIRedactBeforeSend replaces matches of the patterns in the event message,
+// the exception values, and the string values of the event extra.
+func PIIRedactBeforeSend(patterns []*regexp.Regexp, replacement string) BeforeSendFn {
+	redact := func(s string) string {
+		for _, re := range patterns {
+			s = re.ReplaceAllString(s, replacement)
+		}
+		return s
+	}
+	return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		event.Message = redact(event.Message)
+		for i := range event.Exception {
+			event.Exception[i].Value = redact(event.Exception[i].Value)
+		}
+		for key, value := range event.Extra {
+			if str, ok := value.(string); ok {
+				event.Extra[key] = redact(str)
+			}
+		}
+		return event
+	}
+}
+
+// middlewareModule is the import path of this module, see ContextualizeFrames.
+const middlewareModule = "github.com/digitalmint/go-sentry-middleware"
+
+// ContextualizeFrames is a BeforeSend that removes the stack frames of this module from the exceptions,
+// so that the culprit in Sentry is the handler rather than the middleware.
+func ContextualizeFrames(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	for i := range event.Exception {
+		st := event.Exception[i].Stacktrace
+		if st == nil {
+			continue
+		}
+		frames := st.Frames[:0]
+		for _, frame := range st.Frames {
+			if frame.Module != middlewareModule && !strings.HasPrefix(frame.Module, middlewareModule+"/") {
+				frames = append(frames, frame)
+			}
+		}
+		if len(frames) == 0 {
+			event.Exception[i].Stacktrace = nil
+			continue
+		}
+		st.Frames = frames
+	}
+	return event
+}
+
+// SanitizeURLErrors is a BeforeSend that removes the credentials and the query string of the URL of a *url.Error
+// from the exception values, see SanitizeURL. DSNs left in the values are redacted.
+func SanitizeURLErrors(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	var urlErr *url.Error
+	if hint == nil || !errors.As(hint.OriginalException, &urlErr) {
+		return event
+	}
+	sanitized := string(RedactDSN([]byte(urlErr.URL)))
+	if parsed, err := url.Parse(urlErr.URL); err == nil {
+		sanitized = SanitizeURL(parsed)
+	}
+	for i := range event.Exception {
+		value := strings.ReplaceAll(event.Exception[i].Value, urlErr.URL, sanitized)
+		event.Exception[i].Value = string(RedactDSN([]byte(value)))
+	}
+	return event
+}
+
+// Mechanism types set by HubCustomFingerprint.
+const (
+	MechanismHTTPMiddleware = "http.middleware"
+	MechanismPanic          = "panic"
+)
+
+// SetMechanism sets how the error was caught on the top-level exception of the event, the last one.
+// The exception IDs of an exception group are kept.
+func SetMechanism(event *sentry.Event, mechanismType string, handled bool) *sentry.Event {
+	if len(event.Exception) == 0 {
+		return event
+	}
+	exception := &event.Exception[len(event.Exception)-1]
+	if exception.Mechanism == nil {
+		exception.Mechanism = &sentry.Mechanism{}
+	}
+	exception.Mechanism.Type = mechanismType
+	exception.Mechanism.Handled = &handled
+	return event
+}
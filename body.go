@@ -0,0 +1,228 @@
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ResponseBodyForSentry converts a captured response body to the string sent to Sentry.
+// When maxBytes > 0 the body is truncated to at most maxBytes, cutting at a rune boundary so the result stays valid UTF-8.
+// When skipBinary is true, bodies whose Content-Type is not UTF-8 text are not captured: an empty string is returned.
+func ResponseBodyForSentry(body []byte, contentType string, maxBytes int, skipBinary bool) string {
+	// truncate first: a rune cut by the capture limit would make the body look binary
+	if maxBytes > 0 && len(body) > maxBytes {
+		cut := maxBytes
+		// back up to the start of the rune that straddles the limit
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		body = body[:cut]
+	}
+	if skipBinary && !IsUTF8ContentType(contentType, body) {
+		return ""
+	}
+	return strings.ToValidUTF8(string(body), "")
+}
+
+// DefaultChunkedBodyMarker is appended to the body captured from a chunked response,
+// the client may have received more of the body than what was sent before the error.
+const DefaultChunkedBodyMarker = "[chunked-partial]"
+
+// IsChunkedResponse reports whether the response is streamed with chunked transfer encoding:
+// the headers ask for it, or the handler flushed a response without a Content-Length, which net/http then sends chunked.
+func IsChunkedResponse(header http.Header, flushed bool) bool {
+	if flushed && header.Get("Content-Length") == "" {
+		return true
+	}
+	for _, value := range header.Values("Transfer-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(encoding), "chunked") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TruncateToValidUTF8 returns the longest valid UTF-8 prefix of s that is at most maxLen bytes.
+// A rune that would straddle maxLen is dropped, and so is everything from the first invalid byte.
+func TruncateToValidUTF8(s string, maxLen int) string {
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if (r == utf8.RuneError && size == 1) || end+size > maxLen {
+			break
+		}
+		end += size
+	}
+	return s[:end]
+}
+
+// IsUTF8ContentType reports whether a response with the given Content-Type holds UTF-8 text.
+// An explicit charset decides. Without one, textual media types are assumed to be UTF-8.
+// Without a Content-Type at all, the body itself is checked.
+func IsUTF8ContentType(contentType string, body []byte) bool {
+	if contentType == "" {
+		return utf8.Valid(body)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if charset, ok := params["charset"]; ok {
+		switch strings.ToLower(charset) {
+		case "utf-8", "utf8", "us-ascii":
+			return true
+		default:
+			return false
+		}
+	}
+	return isTextMediaType(mediaType)
+}
+
+// DefaultSkipContentTypes are response content types whose body is never useful in an event.
+var DefaultSkipContentTypes = []string{"image/*", "audio/*", "video/*", "application/octet-stream"}
+
+// MatchContentType reports whether contentType matches one of the patterns.
+// A pattern is a media type such as "application/pdf" or a wildcard such as "image/*".
+func MatchContentType(contentType string, patterns []string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	if strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/problem+json":
+		return true
+	}
+	return false
+}
+
+// BodyFieldExtractor tags the event with a field of a JSON response body, e.g. {JSONPath: "error.code", SentryTag: "error_code"}.
+// JSONPath is a dot separated list of object keys, arrays are not supported.
+type BodyFieldExtractor struct {
+	JSONPath  string
+	SentryTag string
+}
+
+// ExtractBodyFields sets a tag for each extractor whose field is in the JSON body.
+// Extractors are skipped when the body is not JSON, the path is absent or the field is an object, an array or null.
+func ExtractBodyFields(scope *sentry.Scope, body []byte, extractors []BodyFieldExtractor) {
+	if len(extractors) == 0 {
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var root any
+	if err := decoder.Decode(&root); err != nil {
+		return
+	}
+	for _, extractor := range extractors {
+		if value, ok := jsonPathValue(root, extractor.JSONPath); ok {
+			scope.SetTag(extractor.SentryTag, value)
+		}
+	}
+}
+
+func jsonPathValue(root any, path string) (string, bool) {
+	value := root
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number, bool:
+		return fmt.Sprint(value), true
+	default:
+		return "", false
+	}
+}
+
+// ResponseBodyAttachmentName is the filename of the attachment added by AttachResponseBody.
+const ResponseBodyAttachmentName = "response_body.txt"
+
+// DefaultMaxAttachmentBytes caps the body attached by AttachResponseBody when no cap is set.
+const DefaultMaxAttachmentBytes = 1 << 20
+
+// AttachResponseBody adds a body longer than maxBytes as an attachment of the event,
+// truncated to maxAttachmentBytes, or DefaultMaxAttachmentBytes when it is 0.
+// The body should then be left out of the event itself.
+// It returns false and does nothing for a body that fits.
+func AttachResponseBody(scope *sentry.Scope, body []byte, contentType string, maxBytes, maxAttachmentBytes int) bool {
+	if maxBytes <= 0 || len(body) <= maxBytes {
+		return false
+	}
+	scope.AddAttachment(&sentry.Attachment{
+		Filename:    ResponseBodyAttachmentName,
+		ContentType: contentType,
+		Payload:     []byte(ResponseBodyForSentry(body, contentType, attachmentCap(maxAttachmentBytes), false)),
+	})
+	return true
+}
+
+// attachmentCap is the number of body bytes captured for AttachResponseBody.
+func attachmentCap(maxAttachmentBytes int) int {
+	if maxAttachmentBytes <= 0 {
+		return DefaultMaxAttachmentBytes
+	}
+	return maxAttachmentBytes
+}
+
+// BodyCaptureLimit is the number of response body bytes the middlewares need to keep.
+func BodyCaptureLimit(maxBodyBytes int, asAttachment bool, maxAttachmentBytes int) int {
+	if asAttachment {
+		return max(maxBodyBytes, attachmentCap(maxAttachmentBytes))
+	}
+	return maxBodyBytes
+}
+
+// ResponseContentTypeTag is the tag set by SetResponseContentTypeTag.
+const ResponseContentTypeTag = "response.content_type"
+
+// SetResponseContentTypeTag tags the scope with the media type of the response without its parameters,
+// e.g. application/json for "application/json; charset=utf-8". A missing or invalid Content-Type is not tagged.
+func SetResponseContentTypeTag(scope *sentry.Scope, contentType string) {
+	if contentType == "" {
+		return
+	}
+	// the media type is returned along with an invalid parameter error
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" {
+		scope.SetTag(ResponseContentTypeTag, mediaType)
+	}
+}
@@ -0,0 +1,153 @@
+package sentry
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestResponseBodyForSentryTruncatesAtRune(t *testing.T) {
+	body := []byte("héllo wörld") // é and ö are two bytes each
+	// 2 bytes cuts through é
+	if got := ResponseBodyForSentry(body, "text/plain; charset=utf-8", 2, true); got != "h" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := ResponseBodyForSentry(body, "text/plain; charset=utf-8", 3, true); got != "hé" {
+		t.Errorf("unexpected %q", got)
+	}
+	got := ResponseBodyForSentry(body, "application/json", 9, true)
+	if !utf8.ValidString(got) || got != "héllo w" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := ResponseBodyForSentry(body, "", 0, true); got != string(body) {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestResponseBodyForSentryRuneAtCaptureLimit(t *testing.T) {
+	// the writers keep maxBytes+1 bytes, here the first 2 of the 3 bytes of €
+	body := []byte("ab€")[:4]
+	if got := ResponseBodyForSentry(body, "", 3, true); got != "ab" {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestResponseBodyForSentrySkipsBinary(t *testing.T) {
+	latin1 := []byte{'c', 'a', 'f', 0xe9}
+	if got := ResponseBodyForSentry(latin1, "text/plain; charset=latin-1", 0, true); got != "" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := ResponseBodyForSentry([]byte{0x89, 'P', 'N', 'G'}, "image/png", 0, true); got != "" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := ResponseBodyForSentry(latin1, "text/plain; charset=latin-1", 0, false); got != "caf" {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestMatchContentType(t *testing.T) {
+	for _, contentType := range []string{"image/png", "audio/mpeg", "video/mp4", "application/octet-stream", "IMAGE/JPEG"} {
+		if !MatchContentType(contentType, DefaultSkipContentTypes) {
+			t.Errorf("expected %s to be skipped", contentType)
+		}
+	}
+	for _, contentType := range []string{"", "text/plain", "application/json; charset=utf-8", "imagery/x"} {
+		if MatchContentType(contentType, DefaultSkipContentTypes) {
+			t.Errorf("expected %s to be captured", contentType)
+		}
+	}
+}
+
+func TestTruncateToValidUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		maxLen int
+		want   string
+	}{
+		{"ascii", "internal server error", 15, "internal server"},
+		{"ascii shorter", "boom", 15, "boom"},
+		{"rune at the boundary", "ab€", 5, "ab€"},
+		{"rune spanning the boundary", "ab€", 4, "ab"},
+		{"emoji spanning the boundary", "🔥🔥", 7, "🔥"},
+		{"invalid byte", "ab\xffcd", 5, "ab"},
+		{"truncated rune", "ab\xe2\x82", 5, "ab"},
+		{"zero", "abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := TruncateToValidUTF8(tt.s, tt.maxLen)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("%s: got %q want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsChunkedResponse(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"chunked"}, true},
+		{[]string{"gzip, Chunked"}, true},
+		{[]string{"identity"}, false},
+	}
+	for _, tt := range tests {
+		header := http.Header{"Transfer-Encoding": tt.values}
+		if got := IsChunkedResponse(header, false); got != tt.want {
+			t.Errorf("IsChunkedResponse(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+	if !IsChunkedResponse(http.Header{}, true) {
+		t.Errorf("expected a flushed response without Content-Length to be chunked")
+	}
+	if IsChunkedResponse(http.Header{"Content-Length": {"12"}}, true) {
+		t.Errorf("expected a flushed response with a Content-Length not to be chunked")
+	}
+}
+
+func TestExtractBodyFields(t *testing.T) {
+	extractors := []BodyFieldExtractor{
+		{JSONPath: "code", SentryTag: "error_code"},
+		{JSONPath: "error.details.retryable", SentryTag: "retryable"},
+		{JSONPath: "error.details.attempts", SentryTag: "attempts"},
+		{JSONPath: "error.missing", SentryTag: "missing"},
+		{JSONPath: "error", SentryTag: "object"},
+		{JSONPath: "code.nested", SentryTag: "through_string"},
+	}
+	tests := []struct {
+		name string
+		body string
+		want map[string]string
+	}{
+		{"nested", `{"code":"RATE_LIMIT","error":{"details":{"retryable":true,"attempts":12345678901234567890}}}`,
+			map[string]string{"error_code": "RATE_LIMIT", "retryable": "true", "attempts": "12345678901234567890"}},
+		{"missing", `{"message":"too many requests"}`, map[string]string{}},
+		{"not JSON", `too many requests`, map[string]string{}},
+		{"array", `[{"code":"RATE_LIMIT"}]`, map[string]string{}},
+	}
+	for _, tt := range tests {
+		hub := sentry.NewHub(nil, sentry.NewScope())
+		ExtractBodyFields(hub.Scope(), []byte(tt.body), extractors)
+		if tags := sentrytest.CapturedScope(hub).Tags(); len(tags) != len(tt.want) || (len(tags) > 0 && !reflect.DeepEqual(tags, tt.want)) {
+			t.Errorf("%s: unexpected tags %v", tt.name, tags)
+		}
+	}
+}
+
+func TestAttachResponseBody(t *testing.T) {
+	scope := sentry.NewScope()
+	if AttachResponseBody(scope, []byte("short"), "text/plain", 10, 0) {
+		t.Errorf("a body that fits must not be attached")
+	}
+	if AttachResponseBody(scope, []byte("long enough"), "text/plain", 0, 0) {
+		t.Errorf("a body must not be attached without a limit")
+	}
+	if !AttachResponseBody(scope, []byte("<html>error page</html>"), "text/html", 6, 0) {
+		t.Errorf("expected the body to be attached")
+	}
+}
@@ -0,0 +1,80 @@
+package sentry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// BreadcrumbOpts configures BreadcrumbMiddleware.
+type BreadcrumbOpts struct {
+	// MaxHeadersIncluded are the request headers added to the breadcrumb, missing headers are skipped
+	MaxHeadersIncluded []string
+	// IncludeQueryParams keeps the query of the breadcrumb url
+	IncludeQueryParams bool
+}
+
+var DefaultBreadcrumbOpts = BreadcrumbOpts{
+	MaxHeadersIncluded: []string{"Accept", "Content-Type"},
+}
+
+// BreadcrumbMiddleware adds an http breadcrumb when the request starts and another one with the status code
+// and the response time in milliseconds when the handler returns.
+// It uses the hub of the request context, or puts a clone of the current hub there.
+// Put it inside the 500 middleware, so that a 500 event has both breadcrumbs.
+func BreadcrumbMiddleware(opts BreadcrumbOpts) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hub := sentry.GetHubFromContext(r.Context())
+			if hub == nil {
+				hub = sentry.CurrentHub().Clone()
+				r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+			}
+			url := *r.URL
+			if !opts.IncludeQueryParams {
+				url.RawQuery = ""
+			}
+			headers := map[string]string{}
+			for _, header := range opts.MaxHeadersIncluded {
+				if value := r.Header.Get(header); value != "" {
+					headers[http.CanonicalHeaderKey(header)] = value
+				}
+			}
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Type:     "http",
+				Category: "request",
+				Message:  r.Method + " " + url.String(),
+				Data: map[string]any{
+					"method":         r.Method,
+					"url":            url.String(),
+					"headers_subset": headers,
+				},
+				Level: sentry.LevelInfo,
+			}, nil)
+
+			recorder, status := StatusRecorder(w)
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+
+			statusCode := status()
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			level := sentry.LevelInfo
+			if statusCode >= 500 {
+				level = sentry.LevelError
+			}
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Type:     "http",
+				Category: "request",
+				Message:  r.Method + " " + url.String() + " " + http.StatusText(statusCode),
+				Data: map[string]any{
+					"status_code":   statusCode,
+					"response_time": time.Since(start).Milliseconds(),
+				},
+				Level: level,
+			}, nil)
+		})
+	}
+}
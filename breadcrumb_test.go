@@ -0,0 +1,64 @@
+package sentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestBreadcrumbMiddleware(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := Middleware500(DefaultSentry500Opts)(BreadcrumbMiddleware(DefaultBreadcrumbOpts)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}),
+	))
+	req := httptest.NewRequest(http.MethodPost, "/orders?token=secret", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected the 500 to be captured, got %d events", len(transport.Events()))
+	}
+	breadcrumbs := transport.Events()[0].Breadcrumbs
+	if len(breadcrumbs) != 2 {
+		t.Fatalf("expected 2 breadcrumbs %v", breadcrumbs)
+	}
+	start, end := breadcrumbs[0], breadcrumbs[1]
+	if start.Type != "http" || start.Category != "request" || start.Data["method"] != http.MethodPost || start.Data["url"] != "/orders" {
+		t.Errorf("unexpected start breadcrumb %+v", start)
+	}
+	if headers := start.Data["headers_subset"].(map[string]string); len(headers) != 1 || headers["Accept"] != "application/json" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if end.Data["status_code"] != http.StatusInternalServerError || end.Level != sentry.LevelError {
+		t.Errorf("unexpected end breadcrumb %+v", end)
+	}
+	if _, ok := end.Data["response_time"].(int64); !ok {
+		t.Errorf("expected the response time %v", end.Data)
+	}
+}
+
+func TestBreadcrumbMiddlewareQueryParams(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := BreadcrumbOpts{IncludeQueryParams: true}
+	handler := BreadcrumbMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sentry.GetHubFromContext(r.Context()) != hub {
+			t.Errorf("expected the hub of the request")
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders?page=2", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	hub.CaptureMessage("after the request")
+	breadcrumbs := transport.Events()[0].Breadcrumbs
+	if len(breadcrumbs) != 2 || breadcrumbs[0].Data["url"] != "/orders?page=2" || breadcrumbs[1].Data["status_code"] != http.StatusOK {
+		t.Errorf("unexpected breadcrumbs %+v", breadcrumbs)
+	}
+}
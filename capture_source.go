@@ -0,0 +1,39 @@
+package sentry
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// CaptureSourceTag is the tag set by TagCaptureSource.
+const CaptureSourceTag = "capture.source"
+
+// CaptureSource identifies the middleware that captured an event,
+// so that events of a stack of middlewares show which layer sent them.
+type CaptureSource string
+
+const (
+	CaptureSourceHTTPMiddleware500 CaptureSource = "http_middleware_500"
+	CaptureSourceGinMiddleware500  CaptureSource = "gin_middleware_500"
+	CaptureSourceGoaMiddleware500  CaptureSource = "goa_middleware_500"
+	CaptureSourcePanicRecovery     CaptureSource = "panic_recovery"
+)
+
+// TagCaptureSource sets the capture.source tag of the event.
+func TagCaptureSource(source CaptureSource) BeforeSendFn {
+	return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		if event.Tags == nil {
+			event.Tags = map[string]string{}
+		}
+		event.Tags[CaptureSourceTag] = string(source)
+		return event
+	}
+}
+
+// SetCaptureSource runs TagCaptureSource for the events of the hub, the middlewares call it on the hub of the request.
+// An empty source does not tag the events.
+func SetCaptureSource(hub *sentry.Hub, source CaptureSource) {
+	if source == "" {
+		return
+	}
+	hub.Scope().AddEventProcessor(sentry.EventProcessor(TagCaptureSource(source)))
+}
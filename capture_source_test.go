@@ -0,0 +1,27 @@
+package sentry
+
+import (
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestTagCaptureSource(t *testing.T) {
+	event := TagCaptureSource(CaptureSourceGinMiddleware500)(&sentry.Event{}, nil)
+	if event.Tags[CaptureSourceTag] != "gin_middleware_500" {
+		t.Errorf("unexpected tags %v", event.Tags)
+	}
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	SetCaptureSource(hub, "")
+	hub.CaptureMessage("untagged")
+	SetCaptureSource(hub, CaptureSourcePanicRecovery)
+	hub.CaptureMessage("tagged")
+	if _, ok := transport.Events()[0].Tags[CaptureSourceTag]; ok {
+		t.Errorf("an empty source must not tag the event %v", transport.Events()[0].Tags)
+	}
+	if source := transport.Events()[1].Tags[CaptureSourceTag]; source != "panic_recovery" {
+		t.Errorf("unexpected capture source %q", source)
+	}
+}
@@ -0,0 +1,148 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// OutgoingRequestError is reported by CapturingTransport for a failed outgoing request.
+// StatusCode is 0 when no response was received, Err is then the connection error.
+type OutgoingRequestError struct {
+	URL        string
+	Method     string
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (ore OutgoingRequestError) Error() string {
+	msg := "outgoing " + ore.Method + " " + ore.URL
+	if ore.Err != nil {
+		return msg + ": " + ore.Err.Error()
+	}
+	return msg + ": " + strconv.Itoa(ore.StatusCode) + " " + ore.Body
+}
+
+func (ore OutgoingRequestError) Unwrap() error {
+	return ore.Err
+}
+
+// Fingerprint groups on the method, host and normalized path, and the status code.
+func (ore OutgoingRequestError) Fingerprint(_ []string) ([]string, error) {
+	u, err := url.Parse(ore.URL)
+	if err != nil {
+		return nil, err
+	}
+	newPath := NormalizeUrlPathForSentry(u, "")
+	return []string{"outgoing", ore.Method, u.Host + newPath, strconv.Itoa(ore.StatusCode)}, nil
+}
+
+func FingerprintOutgoingRequest(err error, fingerprint []string) ([]string, error) {
+	//nolint:errorlint
+	if ex, ok := err.(OutgoingRequestError); ok {
+		return ex.Fingerprint(fingerprint)
+	}
+	return nil, nil
+}
+
+type CapturingTransportOpts struct {
+	// CaptureStatusCodes are the response codes that are reported. Connection errors are always reported.
+	CaptureStatusCodes []int
+	// MaxBodyBytes is the size of the response body snippet
+	MaxBodyBytes    int
+	FingerprintOpts FingerprintOpts
+}
+
+var DefaultCapturingTransportOpts = CapturingTransportOpts{
+	CaptureStatusCodes: []int{500, 502, 503, 504},
+	MaxBodyBytes:       1024,
+	FingerprintOpts: FingerprintOpts{
+		ErrHandler:     DefaultFingerprintErrorHandler,
+		Fingerprinters: []Fingerprint{FingerprintOutgoingRequest},
+	},
+}
+
+// CapturingTransport is an http.RoundTripper that reports failed outgoing requests to Sentry.
+// The response is returned to the caller unchanged.
+type CapturingTransport struct {
+	RT   http.RoundTripper
+	Hub  *sentry.Hub
+	Opts CapturingTransportOpts
+}
+
+func NewCapturingTransport(inner http.RoundTripper, hub *sentry.Hub, opts CapturingTransportOpts) CapturingTransport {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	return CapturingTransport{RT: inner, Hub: hub, Opts: opts}
+}
+
+func (ct CapturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := ct.RT.RoundTrip(req)
+	if err != nil {
+		// the caller gave up, that is not a failure of the remote
+		if !errors.Is(err, context.Canceled) {
+			ct.capture(req, OutgoingRequestError{
+				URL:    SanitizeURL(req.URL),
+				Method: req.Method,
+				Err:    err,
+			})
+		}
+		return resp, err
+	}
+	if !slices.Contains(ct.Opts.CaptureStatusCodes, resp.StatusCode) {
+		return resp, err
+	}
+
+	// read a snippet of the body and put it back for the caller
+	snippet, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(ct.Opts.MaxBodyBytes)+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(snippet), resp.Body), resp.Body}
+	ore := OutgoingRequestError{
+		URL:        SanitizeURL(req.URL),
+		Method:     req.Method,
+		StatusCode: resp.StatusCode,
+	}
+	if readErr == nil {
+		ore.Body = ResponseBodyForSentry(snippet, resp.Header.Get("Content-Type"), ct.Opts.MaxBodyBytes, true)
+	}
+	ct.capture(req, ore)
+	return resp, err
+}
+
+// capture prefers the hub of the request context so that the event has the scope of the incoming request.
+func (ct CapturingTransport) capture(req *http.Request, ore OutgoingRequestError) {
+	hubOrig := sentry.GetHubFromContext(req.Context())
+	if hubOrig == nil {
+		hubOrig = ct.Hub
+	}
+	if hubOrig == nil {
+		hubOrig = sentry.CurrentHub()
+	}
+	hub := HubCustomFingerprint(hubOrig.Clone(), ct.Opts.FingerprintOpts)
+	hub.CaptureException(ore)
+}
+
+// SanitizeURL removes the user info and query string which can contain credentials.
+func SanitizeURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	sanitized := *u
+	sanitized.User = nil
+	sanitized.RawQuery = ""
+	sanitized.ForceQuery = false
+	sanitized.Fragment = ""
+	sanitized.RawFragment = ""
+	return sanitized.String()
+}
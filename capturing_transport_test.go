@@ -0,0 +1,56 @@
+package sentry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+)
+
+func TestCapturingTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("upstream down"))
+	}))
+	defer ts.Close()
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	client := &http.Client{Transport: NewCapturingTransport(nil, hub, DefaultCapturingTransportOpts)}
+	res, err := client.Get(ts.URL + "/users/42?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "upstream down" {
+		t.Errorf("body not restored %q", body)
+	}
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	want := []string{"outgoing", "GET", ts.Listener.Addr().String() + "/users/-omitted-", "503"}
+	if len(event.Fingerprint) != len(want) || event.Fingerprint[2] != want[2] || event.Fingerprint[3] != want[3] {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+	if value := event.Exception[len(event.Exception)-1].Value; value != "outgoing GET "+ts.URL+"/users/42: 503 upstream down" {
+		t.Errorf("unexpected exception value %q", value)
+	}
+}
+
+func TestCapturingTransportConnectionError(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	client := &http.Client{Transport: NewCapturingTransport(nil, hub, DefaultCapturingTransportOpts)}
+	if _, err := client.Get("http://127.0.0.1:1/unreachable"); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+}
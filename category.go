@@ -0,0 +1,60 @@
+package sentry
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ErrorCategory is sent to Sentry in the error.category tag.
+type ErrorCategory string
+
+const (
+	ErrorCategoryBug            ErrorCategory = "bug"
+	ErrorCategoryInfrastructure ErrorCategory = "infrastructure"
+	ErrorCategoryClient         ErrorCategory = "client"
+)
+
+// ErrorCategoryTag is the tag set by SetErrorCategory.
+const ErrorCategoryTag = "error.category"
+
+// ErrorCategorizer separates bugs from infrastructure and client errors.
+// An empty category means the error is not categorized.
+type ErrorCategorizer interface {
+	Categorize(err error) ErrorCategory
+}
+
+type CategoryRule struct {
+	Pattern  *regexp.Regexp
+	Category ErrorCategory
+}
+
+type regexCategorizer struct {
+	rules []CategoryRule
+}
+
+// RegexCategorizer matches the rules in order against the type of the error, e.g. "*url.Error".
+func RegexCategorizer(rules []CategoryRule) ErrorCategorizer {
+	return regexCategorizer{rules: rules}
+}
+
+func (c regexCategorizer) Categorize(err error) ErrorCategory {
+	errType := fmt.Sprintf("%T", err)
+	for _, rule := range c.rules {
+		if rule.Pattern.MatchString(errType) {
+			return rule.Category
+		}
+	}
+	return ""
+}
+
+// SetErrorCategory tags the scope of the hub with the category of err, categorizer can be nil.
+func SetErrorCategory(hub *sentry.Hub, categorizer ErrorCategorizer, err error) {
+	if categorizer == nil {
+		return
+	}
+	if category := categorizer.Categorize(err); category != "" {
+		hub.Scope().SetTag(ErrorCategoryTag, string(category))
+	}
+}
@@ -0,0 +1,42 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+)
+
+func TestRegexCategorizer(t *testing.T) {
+	categorizer := RegexCategorizer([]CategoryRule{
+		{Pattern: regexp.MustCompile(`^\*net\.`), Category: ErrorCategoryInfrastructure},
+		{Pattern: regexp.MustCompile(`SentryError500$`), Category: ErrorCategoryBug},
+	})
+	tests := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, ErrorCategoryInfrastructure},
+		{SentryError500{Url: "/"}, ErrorCategoryBug},
+		{context.Canceled, ""},
+	}
+	for _, tt := range tests {
+		if got := categorizer.Categorize(tt.err); got != tt.want {
+			t.Errorf("%T: got %q want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSetErrorCategory(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	categorizer := RegexCategorizer([]CategoryRule{{Pattern: regexp.MustCompile(`.`), Category: ErrorCategoryClient}})
+	SetErrorCategory(hub, categorizer, SentryError500{Url: "/"})
+	hub.CaptureException(SentryError500{Url: "/"})
+	events := transport.Events()
+	if len(events) != 1 || events[0].Tags[ErrorCategoryTag] != "client" {
+		t.Fatalf("unexpected %v", events)
+	}
+}
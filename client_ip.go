@@ -0,0 +1,97 @@
+package sentry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ClientIP returns the client IP from X-Forwarded-For (the first address), X-Real-IP or the remote address,
+// or "" when none is a valid IP. The headers are set by the client unless a proxy overwrites them.
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := net.ParseIP(strings.TrimSpace(first)); ip != nil {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// AnonymizeIP zeroes the last octet of an IPv4 address and the last 64 bits of an IPv6 address.
+// Invalid addresses return "".
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String()
+}
+
+// ClientIPModifier sets the user IP address of the event from ClientIP, see AnonymizeIP for GDPR.
+// A user set by other modifiers is kept.
+func ClientIPModifier(anonymize bool) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		r := RequestFromContext(ctx)
+		if r == nil {
+			return
+		}
+		ip := ClientIP(r)
+		if anonymize {
+			ip = AnonymizeIP(ip)
+		}
+		if ip == "" {
+			return
+		}
+		hub.Scope().AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			event.User.IPAddress = ip
+			return event
+		})
+	})
+}
+
+// GeoIPEnricher returns the country code and region of an IP address, see the maxmind folder for an implementation.
+type GeoIPEnricher interface {
+	Enrich(ip net.IP) (countryCode, region string, err error)
+}
+
+// GeoIPModifier tags the event with the geo.country and geo.region of ClientIP.
+// Nothing is tagged when the lookup fails, empty values are not tagged.
+func GeoIPModifier(enricher GeoIPEnricher) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		r := RequestFromContext(ctx)
+		if r == nil {
+			return
+		}
+		ip := net.ParseIP(ClientIP(r))
+		if ip == nil {
+			return
+		}
+		countryCode, region, err := enricher.Enrich(ip)
+		if err != nil {
+			return
+		}
+		if countryCode != "" {
+			hub.Scope().SetTag("geo.country", countryCode)
+		}
+		if region != "" {
+			hub.Scope().SetTag("geo.region", region)
+		}
+	})
+}
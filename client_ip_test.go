@@ -0,0 +1,92 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		remoteAddr string
+		want       string
+	}{
+		{"forwarded for", map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1", "X-Real-IP": "198.51.100.1"}, "10.0.0.2:1234", "203.0.113.7"},
+		{"real ip", map[string]string{"X-Real-IP": "198.51.100.1"}, "10.0.0.2:1234", "198.51.100.1"},
+		{"invalid header", map[string]string{"X-Forwarded-For": "unknown"}, "10.0.0.2:1234", "10.0.0.2"},
+		{"remote addr", nil, "[2001:db8::1]:443", "2001:db8::1"},
+		{"none", nil, "pipe", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for key, value := range tt.headers {
+			r.Header.Set(key, value)
+		}
+		if got := ClientIP(r); got != tt.want {
+			t.Errorf("%s: got %q want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":                     "203.0.113.0",
+		"::ffff:203.0.113.7":              "203.0.113.0",
+		"2001:db8:85a3:1:8a2e:370:7334":   "",
+		"2001:db8:85a3:1:2:8a2e:370:7334": "2001:db8:85a3:1::",
+		"":                                "",
+	}
+	for ip, want := range tests {
+		if got := AnonymizeIP(ip); got != want {
+			t.Errorf("%q: got %q want %q", ip, got, want)
+		}
+	}
+}
+
+func TestClientIPModifier(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Real-IP", "198.51.100.23")
+	hub, transport := sentrytest.NewRecordingHub(t)
+	hub.Scope().SetUser(sentry.User{ID: "42"})
+	ClientIPModifier(true).ModifyHub(ContextWithRequest(context.Background(), r), hub)
+	hub.CaptureMessage("with ip")
+
+	if user := transport.Events()[0].User; user.ID != "42" || user.IPAddress != "198.51.100.0" {
+		t.Errorf("unexpected user %v", user)
+	}
+}
+
+type geoIPStub map[string][2]string
+
+func (g geoIPStub) Enrich(ip net.IP) (string, string, error) {
+	geo, ok := g[ip.String()]
+	if !ok {
+		return "", "", errors.New("address not found")
+	}
+	return geo[0], geo[1], nil
+}
+
+func TestGeoIPModifier(t *testing.T) {
+	enricher := geoIPStub{"198.51.100.23": {"FR", "IDF"}}
+	for remoteAddr, want := range map[string]map[string]string{
+		"198.51.100.23:4000": {"geo.country": "FR", "geo.region": "IDF"},
+		"203.0.113.9:4000":   {},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		hub, _ := sentrytest.NewRecordingHub(t)
+		GeoIPModifier(enricher).ModifyHub(ContextWithRequest(context.Background(), r), hub)
+		tags := sentrytest.CapturedScope(hub).Tags()
+		if tags["geo.country"] != want["geo.country"] || tags["geo.region"] != want["geo.region"] {
+			t.Errorf("%s: unexpected tags %v", remoteAddr, tags)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sentry
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ClientOption configures the sentry.ClientOptions built by NewClientOptions.
+type ClientOption func(*sentry.ClientOptions)
+
+// NewClientOptions applies the options in order to empty sentry.ClientOptions.
+func NewClientOptions(opts ...ClientOption) sentry.ClientOptions {
+	options := sentry.ClientOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithHTTPTransport sends the events with rt, client.Flush then also flushes rt once the events are sent.
+// It wraps the Transport set so far, or a sentry.HTTPTransport, so it must come after an option setting the Transport.
+func WithHTTPTransport(rt FlushableTransport) ClientOption {
+	return func(options *sentry.ClientOptions) {
+		options.HTTPTransport = rt
+		transport := options.Transport
+		if transport == nil {
+			transport = sentry.NewHTTPTransport()
+		}
+		options.Transport = flushHTTPTransport{Transport: transport, rt: rt}
+	}
+}
+
+// WithGitCommit uses the commit SHA as the release and tags the events with git.commit.
+func WithGitCommit(sha string) ClientOption {
+	return WithGitInfo(sha, "", "")
+}
+
+// WithGitInfo is WithGitCommit that also tags the events with git.branch and git.repository.
+// Empty values are skipped.
+func WithGitInfo(sha, branch, repoURL string) ClientOption {
+	return func(options *sentry.ClientOptions) {
+		if sha != "" {
+			options.Release = sha
+		}
+		for tag, value := range map[string]string{"git.commit": sha, "git.branch": branch, "git.repository": repoURL} {
+			if value == "" {
+				continue
+			}
+			if options.Tags == nil {
+				options.Tags = map[string]string{}
+			}
+			options.Tags[tag] = value
+		}
+	}
+}
+
+// WithKubernetesContext tags the events with k8s.pod, k8s.namespace and k8s.node from the HOSTNAME,
+// POD_NAMESPACE and NODE_NAME environment variables, the latter two are set with the downward API.
+// Variables that are not set are skipped.
+func WithKubernetesContext() ClientOption {
+	return withKubernetesContext(os.Getenv)
+}
+
+func withKubernetesContext(getenv func(string) string) ClientOption {
+	return func(options *sentry.ClientOptions) {
+		for tag, variable := range map[string]string{"k8s.pod": "HOSTNAME", "k8s.namespace": "POD_NAMESPACE", "k8s.node": "NODE_NAME"} {
+			value := getenv(variable)
+			if value == "" {
+				continue
+			}
+			if options.Tags == nil {
+				options.Tags = map[string]string{}
+			}
+			options.Tags[tag] = value
+		}
+	}
+}
+
+// AutoDetectGitInfo is WithGitInfo from the GIT_COMMIT, GIT_BRANCH and GIT_REPO environment variables
+// that CI/CD commonly sets. Without GIT_COMMIT the vcs.revision stamped by go build is used.
+func AutoDetectGitInfo() ClientOption {
+	return autoDetectGitInfo(os.Getenv, debug.ReadBuildInfo)
+}
+
+func autoDetectGitInfo(getenv func(string) string, readBuildInfo func() (*debug.BuildInfo, bool)) ClientOption {
+	sha := getenv("GIT_COMMIT")
+	if sha == "" {
+		if info, ok := readBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					sha = setting.Value
+				}
+			}
+		}
+	}
+	return WithGitInfo(sha, getenv("GIT_BRANCH"), getenv("GIT_REPO"))
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"errors"
+	"runtime/debug"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWithGitInfo(t *testing.T) {
+	options := NewClientOptions(WithGitCommit("a1b2c3d"))
+	if options.Release != "a1b2c3d" || options.Tags["git.commit"] != "a1b2c3d" || len(options.Tags) != 1 {
+		t.Errorf("unexpected %s %v", options.Release, options.Tags)
+	}
+	options = NewClientOptions(WithGitInfo("a1b2c3d", "main", "https://github.com/digitalmint/app"))
+	if options.Tags["git.branch"] != "main" || options.Tags["git.repository"] != "https://github.com/digitalmint/app" {
+		t.Errorf("unexpected %v", options.Tags)
+	}
+}
+
+func TestAutoDetectGitInfo(t *testing.T) {
+	t.Setenv("GIT_COMMIT", "0f9e8d7")
+	t.Setenv("GIT_REPO", "git@github.com:digitalmint/app.git")
+	if options := NewClientOptions(AutoDetectGitInfo()); options.Release != "0f9e8d7" || options.Tags["git.repository"] != "git@github.com:digitalmint/app.git" {
+		t.Errorf("unexpected %s %v", options.Release, options.Tags)
+	}
+
+	buildInfo := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Settings: []debug.BuildSetting{{Key: "vcs", Value: "git"}, {Key: "vcs.revision", Value: "fromvcs"}}}, true
+	}
+	env := map[string]string{"GIT_COMMIT": "fromenv", "GIT_BRANCH": "release"}
+	options := NewClientOptions(autoDetectGitInfo(func(key string) string { return env[key] }, buildInfo))
+	if options.Release != "fromenv" || options.Tags["git.branch"] != "release" || options.Tags["git.repository"] != "" {
+		t.Errorf("unexpected %s %v", options.Release, options.Tags)
+	}
+
+	delete(env, "GIT_COMMIT")
+	options = NewClientOptions(autoDetectGitInfo(func(key string) string { return env[key] }, buildInfo))
+	if options.Release != "fromvcs" || options.Tags["git.commit"] != "fromvcs" {
+		t.Errorf("expected the vcs.revision fallback %s %v", options.Release, options.Tags)
+	}
+
+	noBuildInfo := func() (*debug.BuildInfo, bool) { return nil, false }
+	options = NewClientOptions(autoDetectGitInfo(func(string) string { return "" }, noBuildInfo))
+	if options.Release != "" || options.Tags != nil {
+		t.Errorf("unexpected %s %v", options.Release, options.Tags)
+	}
+}
+
+func TestWithKubernetesContext(t *testing.T) {
+	t.Setenv("HOSTNAME", "orders-7d9f-x2k")
+	t.Setenv("POD_NAMESPACE", "payments")
+	t.Setenv("NODE_NAME", "node-3")
+	transport := &sentrytest.EventRecorder{}
+	options := NewClientOptions(WithKubernetesContext())
+	options.Dsn = "https://key@o1.ingest.sentry.io/1"
+	options.Transport = transport
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentry.NewHub(client, sentry.NewScope()).CaptureException(errors.New("boom"))
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	tags := transport.Events()[0].Tags
+	if tags["k8s.pod"] != "orders-7d9f-x2k" || tags["k8s.namespace"] != "payments" || tags["k8s.node"] != "node-3" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	env := map[string]string{"HOSTNAME": "orders-7d9f-x2k"}
+	options = NewClientOptions(withKubernetesContext(func(key string) string { return env[key] }))
+	if len(options.Tags) != 1 || options.Tags["k8s.pod"] != "orders-7d9f-x2k" {
+		t.Errorf("expected the missing variables to be skipped %v", options.Tags)
+	}
+}
@@ -0,0 +1,18 @@
+package sentry
+
+// SentryContextKey is the type of the context keys of this package, a distinct type cannot collide
+// with the keys of other packages. It is exported for documentation only: read and write the values
+// with the helpers such as WithSentryTags and SentryTagsFromContext, not with ctx.Value.
+type SentryContextKey int
+
+const (
+	requestKey SentryContextKey = iota
+	tagsKey
+	scopeAccumulatorKey
+	requestTimingKey
+	suppressKey
+	requestErrorKey
+	requestIDKey
+	checkInIDKey
+	routePatternKey
+)
@@ -0,0 +1,53 @@
+package sentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// CronSentryMiddleware wraps a cron job to report its runs to the Sentry monitor monitorSlug:
+// an in_progress check-in when the job starts, then ok, or error when the job returns an error or panics.
+// The job gets the ID of the check-in with CheckInIDFromContext. A nil hub is the current hub.
+func CronSentryMiddleware(monitorSlug string, hub *sentry.Hub) func(jobFn func(context.Context) error) func(context.Context) error {
+	return func(jobFn func(context.Context) error) func(context.Context) error {
+		return func(ctx context.Context) (err error) {
+			hub := hub
+			if hub == nil {
+				hub = sentry.CurrentHub()
+			}
+			start := time.Now()
+			checkInID := hub.CaptureCheckIn(&sentry.CheckIn{
+				MonitorSlug: monitorSlug,
+				Status:      sentry.CheckInStatusInProgress,
+			}, nil)
+			if checkInID != nil {
+				ctx = context.WithValue(ctx, checkInIDKey, *checkInID)
+			}
+
+			status := sentry.CheckInStatusError
+			defer func() {
+				checkIn := &sentry.CheckIn{
+					MonitorSlug: monitorSlug,
+					Status:      status,
+					Duration:    time.Since(start),
+				}
+				if checkInID != nil {
+					checkIn.ID = *checkInID
+				}
+				hub.CaptureCheckIn(checkIn, nil)
+			}()
+			if err = jobFn(ctx); err == nil {
+				status = sentry.CheckInStatusOK
+			}
+			return err
+		}
+	}
+}
+
+// CheckInIDFromContext returns the ID of the check-in of the job run by CronSentryMiddleware.
+func CheckInIDFromContext(ctx context.Context) (sentry.EventID, bool) {
+	id, ok := ctx.Value(checkInIDKey).(sentry.EventID)
+	return id, ok
+}
@@ -0,0 +1,62 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestCronSentryMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		job    func(context.Context) error
+		status sentry.CheckInStatus
+	}{
+		{"ok", func(context.Context) error { return nil }, sentry.CheckInStatusOK},
+		{"error", func(context.Context) error { return errors.New("export failed") }, sentry.CheckInStatusError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub, transport := sentrytest.NewRecordingHub(t)
+			var jobCheckInID sentry.EventID
+			job := CronSentryMiddleware("nightly-export", hub)(func(ctx context.Context) error {
+				jobCheckInID, _ = CheckInIDFromContext(ctx)
+				return test.job(ctx)
+			})
+			_ = job(context.Background())
+
+			if len(transport.Events()) != 2 {
+				t.Fatalf("expected 2 check-ins, got %d", len(transport.Events()))
+			}
+			start, end := transport.Events()[0].CheckIn, transport.Events()[1].CheckIn
+			if start.MonitorSlug != "nightly-export" || start.Status != sentry.CheckInStatusInProgress {
+				t.Errorf("unexpected start check-in %+v", start)
+			}
+			if end.MonitorSlug != "nightly-export" || end.Status != test.status || end.ID != start.ID {
+				t.Errorf("unexpected end check-in %+v", end)
+			}
+			if jobCheckInID == "" || jobCheckInID != start.ID {
+				t.Errorf("expected the check-in ID %q in the job context, got %q", start.ID, jobCheckInID)
+			}
+		})
+	}
+}
+
+func TestCronSentryMiddlewarePanic(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	job := CronSentryMiddleware("nightly-export", hub)(func(context.Context) error {
+		panic("nil map")
+	})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected the panic to be propagated")
+		}
+		if len(transport.Events()) != 2 || transport.Events()[1].CheckIn.Status != sentry.CheckInStatusError {
+			t.Errorf("expected an error check-in %v", transport.Events())
+		}
+	}()
+	_ = job(context.Background())
+}
@@ -0,0 +1,73 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// CulpritExtractor returns the culprit of the event of a request, shown by Sentry as the transaction.
+// Without it the culprit of a 500 error captured by a middleware is the middleware.
+// An empty culprit keeps the transaction of the event.
+type CulpritExtractor func(ctx context.Context, r *http.Request) string
+
+// DefaultCulpritExtractor is the path of the request normalized with NormalizeUrlPathForSentry.
+func DefaultCulpritExtractor(_ context.Context, r *http.Request) string {
+	if r.URL == nil {
+		return ""
+	}
+	return NormalizeUrlPathForSentry(r.URL, "")
+}
+
+// RoutePatternCulpritExtractor is the route pattern set with SetRoutePattern, e.g. /orders/{id},
+// or else DefaultCulpritExtractor. The Gin middleware sets the pattern of the gin route.
+func RoutePatternCulpritExtractor(ctx context.Context, r *http.Request) string {
+	if pattern := RoutePatternFromContext(ctx); pattern != "" {
+		return pattern
+	}
+	return DefaultCulpritExtractor(ctx, r)
+}
+
+// CulpritModifier sets the transaction of the event to the culprit returned by the extractor.
+func CulpritModifier(extractor CulpritExtractor) HubModifier {
+	return TransactionModifier(func(ctx context.Context) string {
+		r := RequestFromContext(ctx)
+		if r == nil {
+			return ""
+		}
+		return extractor(ctx, r)
+	})
+}
+
+type routePatternRecorder struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+// WithRoutePatternRecorder lets SetRoutePattern called on a derived context be seen with this context.
+// The middlewares call it before the handler.
+func WithRoutePatternRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routePatternKey, &routePatternRecorder{})
+}
+
+// SetRoutePattern keeps the route pattern of the request for RoutePatternCulpritExtractor,
+// call it from the router once the route is matched, e.g. with chi.RouteContext(ctx).RoutePattern()
+// or mux.CurrentRoute(r).GetPathTemplate(). It does nothing without WithRoutePatternRecorder.
+func SetRoutePattern(ctx context.Context, pattern string) {
+	if recorder, ok := ctx.Value(routePatternKey).(*routePatternRecorder); ok {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.pattern = pattern
+	}
+}
+
+// RoutePatternFromContext returns the pattern set with SetRoutePattern or "".
+func RoutePatternFromContext(ctx context.Context) string {
+	recorder, ok := ctx.Value(routePatternKey).(*routePatternRecorder)
+	if !ok {
+		return ""
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.pattern
+}
@@ -0,0 +1,78 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestMiddleware500CulpritExtractor(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor CulpritExtractor
+		handler   http.HandlerFunc
+		want      string
+	}{
+		{
+			name:      "custom",
+			extractor: func(context.Context, *http.Request) string { return "orders.Create" },
+			want:      "orders.Create",
+		},
+		{
+			name:      "default",
+			extractor: DefaultCulpritExtractor,
+			want:      "/orders/-omitted-/items",
+		},
+		{
+			name:      "route pattern",
+			extractor: RoutePatternCulpritExtractor,
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				SetRoutePattern(r.Context(), "/orders/{id}/items")
+			},
+			want: "/orders/{id}/items",
+		},
+		{
+			name:      "route pattern not set",
+			extractor: RoutePatternCulpritExtractor,
+			want:      "/orders/-omitted-/items",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub, transport := sentrytest.NewRecordingHub(t)
+			opts := DefaultSentry500Opts
+			opts.CulpritExtractor = test.extractor
+			handler := Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.handler != nil {
+					// the pattern is set on a context derived from the one of the middleware
+					test.handler(w, r.WithContext(context.WithoutCancel(r.Context())))
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/orders/42/items", nil)
+			req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(transport.Events()) != 1 || transport.Events()[0].Transaction != test.want {
+				t.Errorf("expected the transaction %q %v", test.want, transport.Events())
+			}
+		})
+	}
+}
+
+func TestMiddleware500CulpritExtractorNil(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := Middleware500(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if len(transport.Events()) != 1 || transport.Events()[0].Transaction != "" {
+		t.Errorf("expected the transaction to be kept %v", transport.Events())
+	}
+}
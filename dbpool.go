@@ -0,0 +1,97 @@
+package sentry
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type DBPoolWatcherOpts struct {
+	// PollInterval defaults to 10 seconds
+	PollInterval time.Duration
+	// WaitCountThreshold is the number of connections waited for during one poll interval, 0 disables it
+	WaitCountThreshold int64
+	// WaitDurationThreshold is the time spent waiting for connections during one poll interval, 0 disables it
+	WaitDurationThreshold time.Duration
+	// CooldownPeriod is the minimum time between two events
+	CooldownPeriod time.Duration
+}
+
+var DefaultDBPoolWatcherOpts = DBPoolWatcherOpts{
+	PollInterval:          10 * time.Second,
+	WaitCountThreshold:    100,
+	WaitDurationThreshold: time.Second,
+	CooldownPeriod:        10 * time.Minute,
+}
+
+// DBPoolWatcher reports database/sql connection pool exhaustion, see WatchDBPool.
+type DBPoolWatcher struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// WatchDBPool polls db.Stats() in a goroutine and sends a Sentry event when requests waited for a connection
+// more than the thresholds since the previous poll, i.e. MaxOpenConns was reached.
+func WatchDBPool(db *sql.DB, hub *sentry.Hub, opts DBPoolWatcherOpts) *DBPoolWatcher {
+	return watchDBPool(db.Stats, hub, opts)
+}
+
+func watchDBPool(stats func() sql.DBStats, hub *sentry.Hub, opts DBPoolWatcherOpts) *DBPoolWatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultDBPoolWatcherOpts.PollInterval
+	}
+	w := &DBPoolWatcher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(opts.PollInterval)
+		defer ticker.Stop()
+
+		previous := stats()
+		var lastSent time.Time
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			current := stats()
+			waitCount := current.WaitCount - previous.WaitCount
+			waitDuration := current.WaitDuration - previous.WaitDuration
+			previous = current
+
+			exceeded := (opts.WaitCountThreshold > 0 && waitCount > opts.WaitCountThreshold) ||
+				(opts.WaitDurationThreshold > 0 && waitDuration > opts.WaitDurationThreshold)
+			if !exceeded || (!lastSent.IsZero() && time.Since(lastSent) < opts.CooldownPeriod) {
+				continue
+			}
+			lastSent = time.Now()
+			captureDBPoolExhausted(hub, current, waitCount, waitDuration)
+		}
+	}()
+	return w
+}
+
+func captureDBPoolExhausted(hub *sentry.Hub, stats sql.DBStats, waitCount int64, waitDuration time.Duration) {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelWarning
+	event.Message = "database connection pool exhausted"
+	event.Fingerprint = []string{"db_pool_exhausted"}
+	event.Extra = map[string]interface{}{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           waitCount,
+		"wait_duration":        waitDuration.String(),
+	}
+	hub.CaptureEvent(event)
+}
+
+// Stop stops polling and waits for the goroutine to exit. It is safe to call more than once.
+func (w *DBPoolWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+}
@@ -0,0 +1,40 @@
+package sentry
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+)
+
+func TestWatchDBPool(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	var mu sync.Mutex
+	stats := sql.DBStats{MaxOpenConnections: 10, OpenConnections: 10, InUse: 10}
+	statsFn := func() sql.DBStats {
+		mu.Lock()
+		defer mu.Unlock()
+		// every poll sees 5 more waits
+		stats.WaitCount += 5
+		return stats
+	}
+
+	w := watchDBPool(statsFn, hub, DBPoolWatcherOpts{
+		PollInterval:       time.Millisecond,
+		WaitCountThreshold: 4,
+		CooldownPeriod:     time.Hour,
+	})
+	time.Sleep(50 * time.Millisecond)
+	w.Stop()
+	w.Stop()
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event because of the cooldown, got %d", len(transport.Events()))
+	}
+	extra := transport.Events()[0].Extra
+	if extra["wait_count"] != int64(5) || extra["in_use"] != 10 {
+		t.Errorf("unexpected extra %v", extra)
+	}
+}
@@ -0,0 +1,83 @@
+package sentry
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ClientDedupBeforeSend drops events whose last exception has the same type, value and first stack frame
+// as an event sent less than ttl ago. At most cacheSize exceptions are remembered, the least recently seen are forgotten.
+// Events without an exception are always sent. A cacheSize of 0 or less disables the deduplication.
+func ClientDedupBeforeSend(cacheSize int, ttl time.Duration) BeforeSendFn {
+	if cacheSize <= 0 {
+		return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			return event
+		}
+	}
+	cache := &dedupLRU{
+		capacity: cacheSize,
+		order:    list.New(),
+		entries:  make(map[[sha256.Size]byte]*list.Element, cacheSize),
+	}
+	return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		if len(event.Exception) == 0 {
+			return event
+		}
+		if cache.seenWithin(exceptionHash(event.Exception[len(event.Exception)-1]), ttl, time.Now()) {
+			return nil
+		}
+		return event
+	}
+}
+
+func exceptionHash(exception sentry.Exception) [sha256.Size]byte {
+	frame := ""
+	if st := exception.Stacktrace; st != nil && len(st.Frames) > 0 {
+		first := st.Frames[0]
+		frame = fmt.Sprintf("%s.%s %s:%d", first.Module, first.Function, first.Filename, first.Lineno)
+	}
+	return sha256.Sum256([]byte(exception.Type + "\x00" + exception.Value + "\x00" + frame))
+}
+
+type dedupLRUEntry struct {
+	hash     [sha256.Size]byte
+	lastSent time.Time
+}
+
+// dedupLRU is a least recently used cache of the time an exception hash was last sent.
+type dedupLRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is the most recently seen
+	entries  map[[sha256.Size]byte]*list.Element
+}
+
+// seenWithin reports whether hash was sent less than ttl before now, otherwise it is recorded as sent now.
+// The time is not refreshed by dropped events so that a steady stream of an error is still reported once per ttl.
+func (lru *dedupLRU) seenWithin(hash [sha256.Size]byte, ttl time.Duration, now time.Time) bool {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.entries[hash]; ok {
+		lru.order.MoveToFront(elem)
+		entry := elem.Value.(*dedupLRUEntry)
+		if now.Sub(entry.lastSent) < ttl {
+			return true
+		}
+		entry.lastSent = now
+		return false
+	}
+
+	lru.entries[hash] = lru.order.PushFront(&dedupLRUEntry{hash: hash, lastSent: now})
+	for lru.order.Len() > lru.capacity {
+		oldest := lru.order.Back()
+		lru.order.Remove(oldest)
+		delete(lru.entries, oldest.Value.(*dedupLRUEntry).hash)
+	}
+	return false
+}
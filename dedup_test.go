@@ -0,0 +1,75 @@
+package sentry
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestClientDedupBeforeSend(t *testing.T) {
+	transport := &sentrytest.EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:        "https://key@o1.ingest.sentry.io/1",
+		Transport:  transport,
+		BeforeSend: ClientDedupBeforeSend(10, time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	for i := 0; i < 2; i++ {
+		hub.CaptureException(errors.New("connection refused"))
+	}
+	hub.CaptureException(errors.New("no rows"))
+	hub.CaptureMessage("no exception")
+	hub.CaptureMessage("no exception")
+
+	if len(transport.Events()) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(transport.Events()))
+	}
+	if value := transport.Events()[1].Exception[0].Value; value != "no rows" {
+		t.Errorf("unexpected %s", value)
+	}
+}
+
+func TestClientDedupBeforeSendDisabled(t *testing.T) {
+	for _, cacheSize := range []int{0, -1} {
+		transport := &sentrytest.EventRecorder{}
+		client, err := sentry.NewClient(sentry.ClientOptions{
+			Dsn:        "https://key@o1.ingest.sentry.io/1",
+			Transport:  transport,
+			BeforeSend: ClientDedupBeforeSend(cacheSize, time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hub := sentry.NewHub(client, sentry.NewScope())
+		for i := 0; i < 2; i++ {
+			hub.CaptureException(errors.New("connection refused"))
+		}
+		if len(transport.Events()) != 2 {
+			t.Errorf("cache size %d: expected 2 events, got %d", cacheSize, len(transport.Events()))
+		}
+	}
+}
+
+func TestDedupLRU(t *testing.T) {
+	lru := &dedupLRU{capacity: 1}
+	lru.order, lru.entries = list.New(), map[[sha256.Size]byte]*list.Element{}
+	a, b := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b"))
+	now := time.Now()
+	if lru.seenWithin(a, time.Minute, now) || !lru.seenWithin(a, time.Minute, now.Add(time.Second)) {
+		t.Errorf("expected a to be deduplicated within the ttl")
+	}
+	if lru.seenWithin(a, time.Minute, now.Add(2*time.Minute)) {
+		t.Errorf("expected a to be sent after the ttl")
+	}
+	if lru.seenWithin(b, time.Minute, now) || lru.seenWithin(a, time.Minute, now.Add(2*time.Minute)) {
+		t.Errorf("expected a to be evicted by b")
+	}
+}
@@ -0,0 +1,122 @@
+package sentry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+// newExampleHub returns a hub whose events are kept in memory instead of being sent to Sentry.
+func newExampleHub(opts sentry.ClientOptions) (*sentry.Hub, *sentrytest.EventRecorder) {
+	recorder := &sentrytest.EventRecorder{}
+	opts.Dsn = "https://key@o1.ingest.sentry.io/1"
+	opts.Transport = recorder
+	client, err := sentry.NewClient(opts)
+	if err != nil {
+		panic(err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), recorder
+}
+
+func ExampleMiddleware500() {
+	hub, recorder := newExampleHub(sentry.ClientOptions{})
+	handler := mdlwrsentry.Middleware500(mdlwrsentry.DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database unavailable", http.StatusInternalServerError)
+	}))
+
+	// sentryhttp.Handler puts a hub on the request context in a real server
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := recorder.Events()[0]
+	fmt.Println(event.Exception[0].Value)
+	// Output: 500 /orders/42:database unavailable
+}
+
+func ExampleHubModifierFunc() {
+	hub, recorder := newExampleHub(sentry.ClientOptions{})
+	opts := mdlwrsentry.DefaultSentry500Opts
+	opts.HubModifiers = []mdlwrsentry.HubModifier{
+		mdlwrsentry.HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+			r := mdlwrsentry.RequestFromContext(ctx)
+			hub.Scope().SetTag("tenant", r.Header.Get("X-Tenant"))
+		}),
+	}
+	handler := mdlwrsentry.Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	fmt.Println(recorder.Events()[0].Tags["tenant"])
+	// Output: acme
+}
+
+type paymentError struct{}
+
+func (paymentError) Error() string { return "card declined" }
+
+func ExampleSentryBeforeSendUnwrapAndFilterErrorType() {
+	hub, recorder := newExampleHub(sentry.ClientOptions{
+		BeforeSend: mdlwrsentry.SentryBeforeSendUnwrapAndFilterErrorType(mdlwrsentry.UnwrapAndFilterErrorTypeConfig{}),
+	})
+
+	hub.CaptureException(fmt.Errorf("checkout: %w", paymentError{}))
+	hub.CaptureException(errors.New("checkout failed"))
+
+	for _, event := range recorder.Events() {
+		fmt.Println(event.Exception[len(event.Exception)-1].Type)
+	}
+	// Output:
+	// sentry_test.paymentError
+	// errorString
+}
+
+func ExampleLogSentrySendFailures() {
+	// a Sentry that rejects the events
+	ingest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"detail":"invalid event"}`, http.StatusBadRequest)
+	}))
+	defer ingest.Close()
+
+	transport := mdlwrsentry.NewLogSentrySendFailures(http.DefaultTransport)
+	transport.ErrorHandler = func(_ context.Context, err mdlwrsentry.ErrSentryRoundTrip) {
+		fmt.Println(err.Status, err.ExceptionSummary())
+	}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:           strings.Replace(ingest.URL, "http://", "http://key@", 1) + "/1",
+		Transport:     sentry.NewHTTPSyncTransport(),
+		HTTPTransport: transport,
+	})
+	if err != nil {
+		panic(err)
+	}
+	sentry.NewHub(client, sentry.NewScope()).CaptureException(paymentError{})
+
+	sent, failed := transport.Stats()
+	fmt.Println(sent, failed)
+	// Output:
+	// 400 sentry_test.paymentError: card declined
+	// 0 1
+}
+
+func ExampleHubCustomFingerprint() {
+	hubOrig, recorder := newExampleHub(sentry.ClientOptions{})
+	hub := mdlwrsentry.HubCustomFingerprint(hubOrig, mdlwrsentry.DefaultFingerprinter)
+
+	hub.CaptureException(mdlwrsentry.SentryError500{Url: "/orders/42", Body: "database unavailable"})
+
+	fmt.Println(recorder.Events()[0].Fingerprint)
+	// Output: [/orders/-omitted- database unavai]
+}
@@ -0,0 +1,46 @@
+package sentry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// FlushableTransport is an http.RoundTripper that can wait for its pending requests, for example on shutdown.
+// Flush reports whether everything was sent before the timeout.
+type FlushableTransport interface {
+	http.RoundTripper
+	Flush(timeout time.Duration) bool
+}
+
+// MakeFlushable returns rt when it is already a FlushableTransport,
+// otherwise it adds a Flush that has nothing to wait for.
+func MakeFlushable(rt http.RoundTripper) FlushableTransport {
+	if flushable, ok := rt.(FlushableTransport); ok {
+		return flushable
+	}
+	return noopFlushTransport{rt}
+}
+
+type noopFlushTransport struct {
+	http.RoundTripper
+}
+
+func (noopFlushTransport) Flush(time.Duration) bool {
+	return true
+}
+
+// flushHTTPTransport flushes the HTTP transport after the events queued in the Sentry transport.
+type flushHTTPTransport struct {
+	sentry.Transport
+	rt FlushableTransport
+}
+
+func (t flushHTTPTransport) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	if !t.Transport.Flush(timeout) {
+		return false
+	}
+	return t.rt.Flush(time.Until(deadline))
+}
@@ -0,0 +1,61 @@
+package sentry
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+type flushCountingRoundTripper struct {
+	http.RoundTripper
+	requests atomic.Int32
+	flushes  atomic.Int32
+}
+
+func (t *flushCountingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func (t *flushCountingRoundTripper) Flush(time.Duration) bool {
+	t.flushes.Add(1)
+	return true
+}
+
+func TestMakeFlushable(t *testing.T) {
+	counting := &flushCountingRoundTripper{RoundTripper: http.DefaultTransport}
+	if MakeFlushable(counting) != FlushableTransport(counting) {
+		t.Error("expected a FlushableTransport to be returned as is")
+	}
+	plain := MakeFlushable(http.DefaultTransport)
+	if !plain.Flush(time.Second) {
+		t.Error("expected the no-op Flush to succeed")
+	}
+}
+
+func TestWithHTTPTransportFlushChain(t *testing.T) {
+	server := sentrytest.NewServer(t)
+	counting := &flushCountingRoundTripper{RoundTripper: http.DefaultTransport}
+	options := NewClientOptions(WithHTTPTransport(NewLogSentrySendFailures(counting)))
+	options.Dsn = server.DSN()
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.CaptureException(errors.New("boom"), nil, nil)
+	if !client.Flush(5 * time.Second) {
+		t.Fatal("flush timed out")
+	}
+	if counting.requests.Load() != 1 || len(server.Events()) != 1 {
+		t.Errorf("expected the event to be sent through the transport, %d requests", counting.requests.Load())
+	}
+	if counting.flushes.Load() != 1 {
+		t.Errorf("expected the transport to be flushed once, got %d", counting.flushes.Load())
+	}
+}
@@ -0,0 +1,45 @@
+package sentrygin_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	sentrygin "github.com/digitalmint/go-sentry-middleware/gin"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+func ExampleMiddlewareSentry500Opts() {
+	// the events are kept in memory instead of being sent to Sentry
+	recorder := &sentrytest.EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@o1.ingest.sentry.io/1", Transport: recorder})
+	if err != nil {
+		panic(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	opts := sentrygin.DefaultSentry500Opts
+	opts.ExtractContext = func(ctx *gin.Context, scope *sentry.Scope) {
+		scope.SetTag("order_id", ctx.Param("id"))
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sentrygin.MiddlewareSentry500Opts(opts))
+	engine.GET("/orders/:id", func(ctx *gin.Context) {
+		ctx.String(http.StatusInternalServerError, "database unavailable")
+	})
+
+	// sentrygin.New of sentry-go puts a hub on the request context in a real server
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := recorder.Events()[0]
+	fmt.Println(event.Exception[0].Value)
+	fmt.Println(event.Tags["order_id"])
+	// Output:
+	// 500 /orders/42:database unavailable
+	// 42
+}
@@ -0,0 +1,16 @@
+package sentrygin
+
+import (
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// SetGinContext sets the framework context of the scope to gin, its version and the route pattern of the request.
+func SetGinContext(scope *sentry.Scope, ctx *gin.Context) {
+	scope.SetContext(mdlwrsentry.FrameworkContextKey, sentry.Context{
+		"name":    "gin",
+		"version": gin.Version,
+		"route":   ctx.FullPath(),
+	})
+}
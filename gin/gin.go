@@ -2,20 +2,33 @@ package sentrygin
 
 import (
 	"bytes"
+	"math/rand"
+	"slices"
+	"time"
 
 	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
 	"github.com/getsentry/sentry-go"
 	"github.com/gin-gonic/gin"
 )
 
+// FilterErrorType is the type of gin.Error, which wraps the errors added with gin.Context.Error.
+// It is registered with mdlwrsentry.RegisterFilterErrorType when the package is imported.
+const FilterErrorType = "gin.Error"
+
+func init() {
+	mdlwrsentry.RegisterFilterErrorType(FilterErrorType)
+}
+
 type Sentry500Options struct {
-	ExtractContext    func(*gin.Context, *sentry.Scope)
-	NoLogResponseBody bool
-	FingerprintOpts   mdlwrsentry.FingerprintOpts
+	// ExtractContext is applied before HubModifiers, use it for data only available on the gin.Context
+	ExtractContext func(*gin.Context, *sentry.Scope)
+	mdlwrsentry.CaptureOptions
+	// IncludeFrameworkContext sets the framework context of the event, see mdlwrsentry.FrameworkContextKey
+	IncludeFrameworkContext bool
 }
 
 var DefaultSentry500Opts = Sentry500Options{
-	FingerprintOpts: mdlwrsentry.DefaultFingerprinter,
+	CaptureOptions: mdlwrsentry.DefaultCaptureOptions,
 }
 
 func MiddlewareSentry500(ctx *gin.Context) {
@@ -23,34 +36,130 @@ func MiddlewareSentry500(ctx *gin.Context) {
 }
 
 func MiddlewareSentry500Opts(opts Sentry500Options) func(*gin.Context) {
+	if opts.ValidateOnCreate {
+		if errs := ValidateSentry500Options(opts); len(errs) > 0 {
+			panic(mdlwrsentry.FormatOptionErrors("MiddlewareSentry500Opts", errs))
+		}
+	}
 	return func(ctx *gin.Context) {
-		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: ctx.Writer}
+		ctx.Request = ctx.Request.WithContext(mdlwrsentry.WithRoutePatternRecorder(
+			mdlwrsentry.WithRequestErrorRecorder(mdlwrsentry.WithSuppressibleCapture(ctx.Request.Context())),
+		))
+		requestBody := func() []byte { return nil }
+		if opts.CaptureRequestBody {
+			requestBody = mdlwrsentry.RecordRequestBody(ctx.Request, mdlwrsentry.DefaultReplayBodyBytes)
+		}
+		maxBytes := mdlwrsentry.BodyCaptureLimit(opts.MaxBodyBytes, opts.CaptureBodyAsAttachment, opts.MaxAttachmentBytes)
+		blw := &bodyLogWriter{
+			body:             bytes.NewBufferString(""),
+			ResponseWriter:   ctx.Writer,
+			maxBytes:         mdlwrsentry.RedactionCaptureLimit(maxBytes, opts.BodyRedactPatterns),
+			skipContentTypes: opts.SkipContentTypes,
+			lazy:             opts.LazyBodyCapture,
+		}
 		ctx.Writer = blw
+		start := time.Now()
 		ctx.Next()
-		if statusCode := ctx.Writer.Status(); statusCode == 500 {
+		if route := ctx.FullPath(); route != "" && mdlwrsentry.RoutePatternFromContext(ctx.Request.Context()) == "" {
+			mdlwrsentry.SetRoutePattern(ctx.Request.Context(), route)
+		}
+		if opts.MeasureResponseSize {
+			if span := sentry.SpanFromContext(ctx.Request.Context()); span != nil {
+				// Size is -1 when nothing was written
+				span.SetData("response_body_size", int64(max(ctx.Writer.Size(), 0)))
+			}
+		}
+		if slow := opts.SlowRequestCapture; slow != nil && ctx.Writer.Status() < 500 {
+			if elapsed := time.Since(start); elapsed >= slow.Threshold {
+				hub := sentry.GetHubFromContext(ctx.Request.Context())
+				if hub == nil {
+					hub = sentry.CurrentHub().Clone()
+				}
+				mdlwrsentry.CaptureSlowRequest(hub, ctx.Request, elapsed, *slow)
+			}
+		}
+		if statusCode := ctx.Writer.Status(); (statusCode == 500 || slices.Contains(opts.CaptureAsMessage, statusCode)) &&
+			!mdlwrsentry.IsSentrySuppressed(ctx.Request.Context()) &&
+			(opts.PreFilterFunc == nil || opts.PreFilterFunc(mdlwrsentry.RequestError(ctx.Request.Context()), statusCode)) &&
+			(opts.SampleFunc == nil || rand.Float64() < opts.SampleFunc(ctx.Request, statusCode)) {
 			hubOrig := sentry.GetHubFromContext(ctx.Request.Context())
 			if hubOrig == nil {
 				hubOrig = sentry.CurrentHub().Clone()
 			}
 			hub := mdlwrsentry.HubCustomFingerprint(hubOrig, opts.FingerprintOpts)
-			hub.Scope().SetRequest(ctx.Request)
-			urlStr := ""
-			if url := ctx.Request.URL; url != nil {
-				urlStr = url.String()
-			}
+			// The scope is only modified in WithScope, the event is captured before it is popped
+			hub.WithScope(func(scope *sentry.Scope) {
+				mdlwrsentry.SetCaptureSource(hub, mdlwrsentry.CaptureSourceGinMiddleware500)
+				scope.SetRequest(ctx.Request)
+				if opts.IncludeFrameworkContext {
+					SetGinContext(scope, ctx)
+				}
+				urlStr := ""
+				if url := ctx.Request.URL; url != nil {
+					urlStr = url.String()
+				}
 
-			if opts.ExtractContext != nil {
-				opts.ExtractContext(ctx, hub.Scope())
-			}
+				mdlwrsentry.ContextTagsModifier.ModifyHub(ctx.Request.Context(), hub)
+				if opts.ExtractContext != nil {
+					mdlwrsentry.RunRecovered("ExtractContext", func() { opts.ExtractContext(ctx, scope) })
+				}
+				var modifiers []mdlwrsentry.HubModifier
+				if opts.CaptureClientIP {
+					modifiers = append(modifiers, mdlwrsentry.ClientIPModifier(opts.AnonymizeIP))
+				}
+				if opts.GeoIPEnricher != nil {
+					modifiers = append(modifiers, mdlwrsentry.GeoIPModifier(opts.GeoIPEnricher))
+				}
+				if opts.CaptureRequestBody {
+					modifiers = append(modifiers, mdlwrsentry.RequestDataModifier(requestBody))
+				}
+				if opts.CulpritExtractor != nil {
+					modifiers = append(modifiers, mdlwrsentry.CulpritModifier(opts.CulpritExtractor))
+				}
+				modifiers = append(modifiers, opts.HubModifiers...)
+				mdlwrsentry.ApplyHubModifiers(
+					mdlwrsentry.ContextWithRequest(ctx.Request.Context(), ctx.Request), hub, modifiers,
+				)
+				mdlwrsentry.AddSlowRequestBreadcrumb(ctx.Request.Context(), hub)
 
-			err500 := mdlwrsentry.SentryError500{
-				Url:  urlStr,
-				Body: "",
-			}
-			if !opts.NoLogResponseBody {
-				err500.Body = blw.body.String()
-			}
-			hub.CaptureException(err500)
+				if statusCode != 500 {
+					mdlwrsentry.CaptureStatusMessage(hub, statusCode, urlStr)
+					return
+				}
+
+				err500 := mdlwrsentry.SentryError500{
+					Url:      urlStr,
+					Body:     "",
+					Severity: opts.DefaultSeverity,
+				}
+				body := mdlwrsentry.RedactSensitiveData(blw.body.Bytes(), opts.BodyRedactPatterns)
+				if !opts.NoLogResponseBody {
+					contentType := ctx.Writer.Header().Get("Content-Type")
+					err500.Body = mdlwrsentry.ResponseBodyForSentry(body, contentType, opts.MaxBodyBytes, opts.SkipBinaryBodyCapture)
+					if opts.CaptureBodyAsAttachment && err500.Body != "" &&
+						mdlwrsentry.AttachResponseBody(scope, body, contentType, opts.MaxBodyBytes, opts.MaxAttachmentBytes) {
+						err500.Body = ""
+					}
+				}
+				mdlwrsentry.ExtractBodyFields(scope, body, opts.ResponseBodyFields)
+				if opts.CaptureResponseContentType {
+					mdlwrsentry.SetResponseContentTypeTag(scope, ctx.Writer.Header().Get("Content-Type"))
+				}
+				if mdlwrsentry.IsChunkedResponse(ctx.Writer.Header(), blw.flushed) {
+					scope.SetExtra("response_encoding", "chunked")
+					if err500.Body != "" {
+						err500.Body += opts.ChunkedBodyMarker
+					}
+				}
+				if opts.CaptureRequestReplay {
+					scope.SetExtra(mdlwrsentry.ReplayExtraKey, mdlwrsentry.RequestReplay(ctx.Request, requestBody()))
+				}
+				if level := mdlwrsentry.MethodLevel(ctx.Request.Method, opts.MethodLevelMap, opts.DefaultLevel); level != "" {
+					scope.SetLevel(level)
+				}
+				mdlwrsentry.SetErrorCategory(hub, opts.ErrorCategorizer, err500)
+				mdlwrsentry.CaptureExceptionWithTimeout(hub, err500, opts.CaptureTimeout)
+			})
 		}
 	}
 }
@@ -58,9 +167,38 @@ func MiddlewareSentry500Opts(opts Sentry500Options) func(*gin.Context) {
 type bodyLogWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
+	// maxBytes stops body capture once the body is known to need truncation, 0 means no limit
+	maxBytes         int
+	skipContentTypes []string
+	lazy             bool
+	// flushed is set when the handler flushed the response, see mdlwrsentry.IsChunkedResponse
+	flushed bool
+}
+
+// WriteString is captured like Write, gin.ResponseWriter would otherwise write the string directly.
+func (w bodyLogWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bodyLogWriter) Flush() {
+	w.flushed = true
+	w.ResponseWriter.Flush()
 }
 
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if (!w.lazy || w.Status() == 500) && !mdlwrsentry.MatchContentType(w.Header().Get("Content-Type"), w.skipContentTypes) {
+		captured := b
+		if w.maxBytes > 0 {
+			// one byte past the limit is kept so that truncation can find the rune boundary
+			room := w.maxBytes + 1 - w.body.Len()
+			if room < 0 {
+				room = 0
+			}
+			if room < len(captured) {
+				captured = captured[:room]
+			}
+		}
+		w.body.Write(captured)
+	}
 	return w.ResponseWriter.Write(b)
 }
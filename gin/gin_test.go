@@ -0,0 +1,414 @@
+package sentrygin
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// serveGin sends a GET of path to an engine with the middleware and a handler on route,
+// and returns the events captured.
+func serveGin(t *testing.T, opts Sentry500Options, route, path string, handler gin.HandlerFunc) []*sentry.Event {
+	t.Helper()
+	return serveGinRequest(t, opts, route, httptest.NewRequest(http.MethodGet, path, nil), handler)
+}
+
+// serveGinRequest is serveGin with a request of any method, the recording hub is set on its context.
+func serveGinRequest(t *testing.T, opts Sentry500Options, route string, req *http.Request, handler gin.HandlerFunc) []*sentry.Event {
+	t.Helper()
+	hub, recorder := sentrytest.NewRecordingHub(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareSentry500Opts(opts))
+	engine.Handle(req.Method, route, handler)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	return recorder.Events()
+}
+
+func TestSuppressSentryCapture(t *testing.T) {
+	events := serveGin(t, DefaultSentry500Opts, "/orders/:id", "/orders/42", func(c *gin.Context) {
+		SuppressSentryCapture(c)
+		c.String(http.StatusInternalServerError, "expected failure")
+	})
+	if len(events) != 0 {
+		t.Errorf("expected the capture to be suppressed, got %d events", len(events))
+	}
+
+	events = serveGin(t, DefaultSentry500Opts, "/orders/:id", "/orders/42", func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, "unexpected failure")
+	})
+	if len(events) != 1 {
+		t.Errorf("expected 1 event, got %d", len(events))
+	}
+}
+
+func TestBodyLogWriterMaxBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	blw := bodyLogWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}, maxBytes: 4}
+	for _, chunk := range []string{"abc", "def", "ghi"} {
+		if n, err := blw.Write([]byte(chunk)); n != 3 || err != nil {
+			t.Fatalf("unexpected write %d %v", n, err)
+		}
+	}
+	if blw.body.String() != "abcde" {
+		t.Errorf("expected maxBytes+1 bytes to be captured, got %q", blw.body.String())
+	}
+}
+
+type ginTestErr struct{}
+
+func (ginTestErr) Error() string { return "gin test" }
+
+func TestFilterErrorType(t *testing.T) {
+	if !slices.Contains(mdlwrsentry.FilterErrorTypes(), FilterErrorType) {
+		t.Errorf("expected gin.Error to be registered %v", mdlwrsentry.FilterErrorTypes())
+	}
+	beforeSend := mdlwrsentry.SentryBeforeSendUnwrapAndFilterErrorType(mdlwrsentry.UnwrapAndFilterErrorTypeConfig{})
+	event := &sentry.Event{Exception: []sentry.Exception{{Type: "*gin.Error"}}}
+	err := &gin.Error{Err: ginTestErr{}, Type: gin.ErrorTypePrivate}
+	if typ := beforeSend(event, &sentry.EventHint{OriginalException: err}).Exception[0].Type; typ != "sentrygin.ginTestErr" {
+		t.Errorf("expected gin.Error to be filtered, got %s", typ)
+	}
+}
+
+func TestMeasureResponseSizeWithoutBody(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.MeasureResponseSize = true
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	span := sentry.StartSpan(req.Context(), "http.server")
+	events := serveGinRequest(t, opts, "/orders/:id", req.WithContext(span.Context()), func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	if len(events) != 1 || events[0].Exception[len(events[0].Exception)-1].Value != "500 /orders/42:" {
+		t.Errorf("expected the 500 without a body to be captured %v", events)
+	}
+	if size := span.Data["response_body_size"]; size != int64(0) {
+		t.Errorf("unexpected response_body_size %v", size)
+	}
+}
+
+type staticGeoIP struct{}
+
+func (staticGeoIP) Enrich(net.IP) (string, string, error) { return "FR", "IDF", nil }
+
+func lastException(event *sentry.Event) string {
+	if len(event.Exception) == 0 {
+		return ""
+	}
+	return event.Exception[len(event.Exception)-1].Value
+}
+
+// TestCaptureOptions runs the options shared with the other middlewares through the gin middleware.
+func TestCaptureOptions(t *testing.T) {
+	fail := func(c *gin.Context) { c.String(http.StatusInternalServerError, "database unavailable") }
+	tests := []struct {
+		name    string
+		modify  func(*Sentry500Options)
+		method  string
+		body    string
+		handler gin.HandlerFunc
+		check   func(events []*sentry.Event) string
+	}{
+		{
+			name: "BodyRedactPatterns",
+			modify: func(o *Sentry500Options) {
+				o.BodyRedactPatterns = []mdlwrsentry.RedactPattern{mdlwrsentry.CreditCardPattern}
+			},
+			handler: func(c *gin.Context) {
+				c.String(http.StatusInternalServerError, "card 4111 1111 1111 1111 declined")
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || strings.Contains(lastException(events[0]), "4111") {
+					return "expected the card number to be redacted"
+				}
+				return ""
+			},
+		},
+		{
+			name: "BodyRedactPatternsAtLimit",
+			modify: func(o *Sentry500Options) {
+				o.BodyRedactPatterns = []mdlwrsentry.RedactPattern{mdlwrsentry.CreditCardPattern}
+				o.MaxBodyBytes = len("card 4111 11")
+			},
+			handler: func(c *gin.Context) {
+				c.String(http.StatusInternalServerError, "card 4111 1111 1111 1111 declined")
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || strings.Contains(lastException(events[0]), "4111") {
+					return "expected the card number crossing the limit to be redacted"
+				}
+				return ""
+			},
+		},
+		{
+			name: "ErrorCategorizer",
+			modify: func(o *Sentry500Options) {
+				o.ErrorCategorizer = mdlwrsentry.RegexCategorizer([]mdlwrsentry.CategoryRule{
+					{Pattern: regexp.MustCompile("SentryError500"), Category: mdlwrsentry.ErrorCategoryBug},
+				})
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Tags[mdlwrsentry.ErrorCategoryTag] != "bug" {
+					return "expected the bug category"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "CaptureClientIP",
+			modify: func(o *Sentry500Options) { o.CaptureClientIP, o.AnonymizeIP = true, true },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].User.IPAddress != "192.0.2.0" {
+					return "expected the anonymized client IP"
+				}
+				return ""
+			},
+		},
+		{
+			name: "MethodLevelMap",
+			modify: func(o *Sentry500Options) {
+				o.MethodLevelMap = map[string]sentry.Level{http.MethodDelete: sentry.LevelFatal}
+			},
+			method: http.MethodDelete,
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Level != sentry.LevelFatal {
+					return "expected the fatal level of DELETE"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "ChunkedBodyMarker",
+			modify: func(o *Sentry500Options) { o.ChunkedBodyMarker = "[partial]" },
+			handler: func(c *gin.Context) {
+				c.Header("Transfer-Encoding", "chunked")
+				fail(c)
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || !strings.HasSuffix(lastException(events[0]), "[partial]") ||
+					events[0].Extra["response_encoding"] != "chunked" {
+					return "expected the chunked marker"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "ChunkedBodyMarkerFlushed",
+			modify: func(o *Sentry500Options) { o.ChunkedBodyMarker = "[partial]" },
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusInternalServerError)
+				_, _ = c.Writer.WriteString("row 1\n")
+				c.Writer.Flush()
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || lastException(events[0]) != "500 /orders/42:row 1\n[partial]" {
+					return "expected a flushed response without Content-Length to be chunked"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "CaptureRequestReplay",
+			modify: func(o *Sentry500Options) { o.CaptureRequestReplay = true },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Extra[mdlwrsentry.ReplayExtraKey] == nil {
+					return "expected the replay extra"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "IncludeFrameworkContext",
+			modify: func(o *Sentry500Options) { o.IncludeFrameworkContext = true },
+			check: func(events []*sentry.Event) string {
+				framework := events[0].Contexts[mdlwrsentry.FrameworkContextKey]
+				if framework["name"] != "gin" || framework["route"] != "/orders/:id" {
+					return "expected the gin framework context"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "SampleFunc",
+			modify: func(o *Sentry500Options) { o.SampleFunc = func(*http.Request, int) float64 { return 0 } },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 0 {
+					return "expected the event to be sampled out"
+				}
+				return ""
+			},
+		},
+		{
+			name: "SlowRequestCapture",
+			modify: func(o *Sentry500Options) {
+				o.SlowRequestCapture = &mdlwrsentry.SlowRequestOpts{Threshold: time.Nanosecond}
+			},
+			handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Level != sentry.LevelWarning {
+					return "expected a slow request warning"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "GeoIPEnricher",
+			modify: func(o *Sentry500Options) { o.GeoIPEnricher = staticGeoIP{} },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Tags["geo.country"] != "FR" || events[0].Tags["geo.region"] != "IDF" {
+					return "expected the geo tags"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "CaptureRequestBody",
+			modify: func(o *Sentry500Options) { o.CaptureRequestBody = true },
+			method: http.MethodPost,
+			body:   "quantity=3",
+			handler: func(c *gin.Context) {
+				// the body is recorded as the handler reads it
+				c.String(http.StatusInternalServerError, "invalid quantity "+c.PostForm("quantity"))
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Request == nil || !strings.Contains(events[0].Request.Data, "quantity=3") {
+					return "expected the request data"
+				}
+				return ""
+			},
+		},
+		{
+			name: "ResponseBodyFields",
+			modify: func(o *Sentry500Options) {
+				o.ResponseBodyFields = []mdlwrsentry.BodyFieldExtractor{{JSONPath: "code", SentryTag: "error_code"}}
+			},
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusInternalServerError, gin.H{"code": "db_down"})
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Tags["error_code"] != "db_down" {
+					return "expected the error_code tag"
+				}
+				return ""
+			},
+		},
+		{
+			name: "CaptureBodyAsAttachment",
+			modify: func(o *Sentry500Options) {
+				o.MaxBodyBytes, o.CaptureBodyAsAttachment, o.MaxAttachmentBytes = 5, true, 8
+			},
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || len(events[0].Attachments) != 1 ||
+					string(events[0].Attachments[0].Payload) != "database" || strings.Contains(lastException(events[0]), "data") {
+					return "expected the body only in a truncated attachment"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "PreFilterFunc",
+			modify: func(o *Sentry500Options) { o.PreFilterFunc = func(error, int) bool { return false } },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 0 {
+					return "expected the event to be filtered"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "CaptureResponseContentType",
+			modify: func(o *Sentry500Options) { o.CaptureResponseContentType = true },
+			check: func(events []*sentry.Event) string {
+				if len(events) != 1 || events[0].Tags[mdlwrsentry.ResponseContentTypeTag] != "text/plain" {
+					return "expected the content type tag"
+				}
+				return ""
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultSentry500Opts
+			tt.modify(&opts)
+			method, handler := tt.method, tt.handler
+			if method == "" {
+				method = http.MethodGet
+			}
+			if handler == nil {
+				handler = fail
+			}
+			req := httptest.NewRequest(method, "/orders/42", strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			events := serveGinRequest(t, opts, "/orders/:id", req, handler)
+			if msg := tt.check(events); msg != "" {
+				t.Errorf("%s, got %d events", msg, len(events))
+			}
+		})
+	}
+}
+
+func TestMiddlewareSentry500ScopeIsolation(t *testing.T) {
+	for _, captureTimeout := range []time.Duration{0, time.Minute} {
+		t.Run(fmt.Sprint("CaptureTimeout=", captureTimeout), func(t *testing.T) {
+			hub, transport := sentrytest.NewRecordingHub(t)
+			opts := DefaultSentry500Opts
+			opts.CaptureTimeout = captureTimeout
+			opts.ExtractContext = func(c *gin.Context, scope *sentry.Scope) {
+				scope.SetUser(sentry.User{ID: c.Query("user")})
+				scope.SetTag("user", c.Query("user"))
+			}
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.Use(MiddlewareSentry500Opts(opts))
+			engine.GET("/orders", func(c *gin.Context) {
+				c.Status(http.StatusInternalServerError)
+			})
+
+			const requests = 50
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func(user string) {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodGet, "/orders?user="+user, nil)
+					req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+					engine.ServeHTTP(httptest.NewRecorder(), req)
+				}(fmt.Sprint(i))
+			}
+			wg.Wait()
+
+			if len(transport.Events()) != requests {
+				t.Fatalf("expected %d events, got %d", requests, len(transport.Events()))
+			}
+			for _, event := range transport.Events() {
+				if want := "user=" + event.User.ID; event.Request.QueryString != want || event.Tags["user"] != event.User.ID {
+					t.Errorf("event of %q has the data of another request %q %v", event.Request.QueryString, event.User.ID, event.Tags)
+				}
+			}
+			scope := sentrytest.CapturedScope(hub)
+			if user := scope.User(); user.ID != "" {
+				t.Errorf("the shared hub scope got the user %q", user.ID)
+			}
+			if tags := scope.Tags(); tags["user"] != "" {
+				t.Errorf("the shared hub scope got the tags %v", tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package sentrygin
+
+import (
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SuppressSentryCapture stops the middleware from sending the 500 of this request to Sentry,
+// for a gin handler that returns a 500 on purpose.
+// mdlwrsentry.SuppressSentryCapture does not work with a *gin.Context: its Value does not read the request context
+// unless gin.Engine.ContextWithFallback is set, and the returned context would be dropped.
+func SuppressSentryCapture(c *gin.Context) {
+	c.Request = c.Request.WithContext(mdlwrsentry.SuppressSentryCapture(c.Request.Context()))
+}
@@ -0,0 +1,19 @@
+package sentrygin
+
+import (
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+)
+
+// ValidateSentry500Options returns all the problems of the options, so that they can be fixed at once.
+func ValidateSentry500Options(opts Sentry500Options) []error {
+	return mdlwrsentry.ValidateSentry500Options(opts.middlewareOptions())
+}
+
+// middlewareOptions returns the mdlwrsentry.Sentry500Options with the same settings, for the validation.
+// ExtractContext takes a *gin.Context and is not converted.
+func (opts Sentry500Options) middlewareOptions() mdlwrsentry.Sentry500Options {
+	return mdlwrsentry.Sentry500Options{
+		CaptureOptions: opts.CaptureOptions,
+		CaptureSource:  mdlwrsentry.CaptureSourceGinMiddleware500,
+	}
+}
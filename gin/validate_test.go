@@ -0,0 +1,60 @@
+package sentrygin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestValidateSentry500Options(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Sentry500Options)
+		want   string
+	}{
+		{"MaxBodyBytes", func(o *Sentry500Options) { o.MaxBodyBytes = -1 }, "MaxBodyBytes must not be negative, got -1"},
+		{"MaxAttachmentBytes", func(o *Sentry500Options) { o.MaxAttachmentBytes = -1 }, "MaxAttachmentBytes must not be negative, got -1"},
+		{"CaptureAsMessage", func(o *Sentry500Options) { o.CaptureAsMessage = []int{42} }, "CaptureAsMessage has an invalid status code 42"},
+		{"MethodLevelMap", func(o *Sentry500Options) { o.MethodLevelMap = map[string]sentry.Level{"GET": "loud"} },
+			`MethodLevelMap[GET] is not a Sentry level, got "loud"`},
+		{"DefaultLevel", func(o *Sentry500Options) { o.DefaultLevel = "warn" }, `DefaultLevel is not a Sentry level, got "warn"`},
+		{"BodyRedactPatterns", func(o *Sentry500Options) { o.BodyRedactPatterns = []mdlwrsentry.RedactPattern{{}} },
+			"BodyRedactPatterns[0].Regex is nil"},
+		{"SlowRequestCapture", func(o *Sentry500Options) { o.SlowRequestCapture = &mdlwrsentry.SlowRequestOpts{} },
+			"SlowRequestCapture.Threshold must be positive, got 0s"},
+		{"ErrHandler", func(o *Sentry500Options) { o.FingerprintOpts.ErrHandler = nil },
+			"FingerprintOpts.ErrHandler must be set when there are Fingerprinters"},
+		{"Fingerprinters", func(o *Sentry500Options) {
+			o.FingerprintOpts.Fingerprinters = []mdlwrsentry.Fingerprint{nil}
+		}, "FingerprintOpts.Fingerprinters[0] is nil"},
+	}
+	for _, tt := range tests {
+		opts := DefaultSentry500Opts
+		tt.modify(&opts)
+		errs := ValidateSentry500Options(opts)
+		if len(errs) != 1 || !errors.Is(errs[0], mdlwrsentry.ErrInvalidOption) || !strings.HasSuffix(errs[0].Error(), tt.want) {
+			t.Errorf("%s: unexpected errors %v", tt.name, errs)
+		}
+	}
+	if errs := ValidateSentry500Options(DefaultSentry500Opts); len(errs) != 0 {
+		t.Errorf("expected the default options to be valid %v", errs)
+	}
+}
+
+func TestMiddlewareSentry500OptsValidateOnCreate(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.ValidateOnCreate = true
+	opts.MaxBodyBytes = -1
+	opts.DefaultLevel = "warn"
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.HasPrefix(msg, "MiddlewareSentry500Opts: 2 invalid options\n- ") || !strings.Contains(msg, "DefaultLevel") {
+			t.Errorf("unexpected panic %q", msg)
+		}
+	}()
+	MiddlewareSentry500Opts(opts)
+}
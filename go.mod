@@ -1,10 +1,12 @@
 module github.com/digitalmint/go-sentry-middleware
 
-go 1.22
+go 1.22.0
 
 require (
 	github.com/getsentry/sentry-go v0.31.1
 	github.com/gin-gonic/gin v1.10.0
+	github.com/oschwald/maxminddb-golang v1.13.1
+	golang.org/x/net v0.34.0
 )
 
 require (
@@ -29,7 +31,6 @@ require (
 	github.com/ugorji/go/codec v1.2.12 // indirect
 	golang.org/x/arch v0.13.0 // indirect
 	golang.org/x/crypto v0.32.0 // indirect
-	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/protobuf v1.36.2 // indirect
@@ -0,0 +1,53 @@
+package mdlwrsentrygoa
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// GoaErrorFields are the fields of an error response encoded by goahttp.
+type GoaErrorFields struct {
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	Temporary bool   `json:"temporary"`
+	Timeout   bool   `json:"timeout"`
+}
+
+var ErrNotGoaError = errors.New("response body is not a Goa error")
+
+// DecodeGoaError decodes a response body encoded by goahttp's error encoder.
+// A JSON body without an error name is not considered to be a Goa error.
+func DecodeGoaError(body []byte) (*GoaErrorFields, error) {
+	fields := GoaErrorFields{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	if fields.Name == "" {
+		return nil, ErrNotGoaError
+	}
+	return &fields, nil
+}
+
+// SetTags adds the error fields as tags on the scope.
+func (gef GoaErrorFields) SetTags(scope *sentry.Scope) {
+	scope.SetTag("goa.error_name", gef.Name)
+	if gef.ID != "" {
+		scope.SetTag("goa.error_id", gef.ID)
+	}
+	scope.SetTag("goa.temporary", strconv.FormatBool(gef.Temporary))
+	scope.SetTag("goa.timeout", strconv.FormatBool(gef.Timeout))
+}
+
+// decodeGoaErrorBody tags the scope with the fields of a Goa error body and returns its name.
+func decodeGoaErrorBody(scope *sentry.Scope, body []byte) string {
+	goaErr, err := DecodeGoaError(body)
+	if err != nil {
+		return ""
+	}
+	goaErr.SetTags(scope)
+	return goaErr.Name
+}
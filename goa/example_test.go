@@ -0,0 +1,40 @@
+package mdlwrsentrygoa_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	mdlwrsentrygoa "github.com/digitalmint/go-sentry-middleware/goa"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func ExampleMiddlewareSentry500() {
+	// the events are kept in memory instead of being sent to Sentry
+	recorder := &sentrytest.EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@o1.ingest.sentry.io/1", Transport: recorder})
+	if err != nil {
+		panic(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	opts := mdlwrsentrygoa.DefaultSentry500Opts
+	opts.DecodeGoaErrors = true
+	// the handler of a goahttp server
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"name":"db_down","id":"a1","message":"database unavailable"}`))
+	})
+	handler = mdlwrsentrygoa.MiddlewareSentry500(opts)(handler)
+
+	// sentryhttp.Handler puts a hub on the request context in a real server
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := recorder.Events()[0]
+	fmt.Println(event.Tags["goa.error_name"], event.Tags["goa.error_id"])
+	// Output: db_down a1
+}
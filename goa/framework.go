@@ -0,0 +1,38 @@
+package mdlwrsentrygoa
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+)
+
+const goaModulePath = "goa.design/goa/v3"
+
+// goaVersion is the version of Goa in the build info of the binary, this package does not depend on Goa.
+var goaVersion = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == goaModulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+})
+
+// SetGoaContext sets the framework context of the scope to goa, its version, the normalized path of the request
+// and the endpoint name when it is not empty. The middleware runs before the Goa mux so it does not know the endpoint.
+func SetGoaContext(scope *sentry.Scope, r *http.Request, endpointName string) {
+	framework := sentry.Context{
+		"name":    "goa",
+		"version": goaVersion(),
+		"route":   mdlwrsentry.NormalizeUrlPathForSentry(r.URL, ""),
+	}
+	if endpointName != "" {
+		framework["endpoint"] = endpointName
+	}
+	scope.SetContext(mdlwrsentry.FrameworkContextKey, framework)
+}
@@ -8,74 +8,54 @@ import (
 	"github.com/getsentry/sentry-go"
 )
 
+// FilterErrorType is the type prefix of the goa errors, goa.ServiceError wraps the errors returned by the service methods.
+// It is registered with mdlwrsentry.RegisterFilterErrorType when the package is imported.
+const FilterErrorType = "goa."
+
+func init() {
+	mdlwrsentry.RegisterFilterErrorType(FilterErrorType)
+}
+
 type Sentry500Options struct {
-	ExtractContext    func(context.Context, *sentry.Scope)
-	NoLogResponseBody bool
-	FingerprintOpts   mdlwrsentry.FingerprintOpts
+	// Deprecated: use HubModifiers, ExtractContext is applied before them.
+	ExtractContext func(context.Context, *sentry.Scope)
+	mdlwrsentry.CaptureOptions
+	// DecodeGoaErrors tags the event with the fields of a Goa encoded error body
+	// and groups on the error name instead of the body. The body is decoded before MaxBodyBytes truncation.
+	DecodeGoaErrors bool
+	// IncludeFrameworkContext sets the framework context of the event, see mdlwrsentry.FrameworkContextKey
+	IncludeFrameworkContext bool
 }
 
 var DefaultSentry500Opts = Sentry500Options{
-	FingerprintOpts: mdlwrsentry.DefaultFingerprinter,
+	CaptureOptions: mdlwrsentry.DefaultCaptureOptions,
 }
 
 // MiddlewareSentry500 is a Goa middleware that captures the response status code and sends to Sentry if code=500.
+// It is mdlwrsentry.Middleware500 with the Goa error decoding and framework context.
 func MiddlewareSentry500(opts Sentry500Options) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Create a custom response writer to capture the status code
-			captureWriter := &statusCaptureResponseWriter{ResponseWriter: w}
-
-			// Call the next middleware/handler in the chain
-			next.ServeHTTP(captureWriter, r)
-
-			// Retrieve the captured response status code
-			respStatus := captureWriter.statusCode
-			if respStatus == 500 {
-				ctx := r.Context()
-				hubOrig := sentry.GetHubFromContext(ctx)
-				if hubOrig == nil {
-					hubOrig = sentry.CurrentHub().Clone()
-				}
-				hub := mdlwrsentry.HubCustomFingerprint(hubOrig, opts.FingerprintOpts)
-				hub.Scope().SetRequest(r)
-				urlStr := ""
-				if url := r.URL; url != nil {
-					urlStr = url.String()
-				}
-
-				if opts.ExtractContext != nil {
-					opts.ExtractContext(ctx, hub.Scope())
-				}
-
-				err500 := mdlwrsentry.SentryError500{
-					Url:  urlStr,
-					Body: "",
-				}
-				if !opts.NoLogResponseBody {
-					err500.Body = captureWriter.body
-				}
-				hub.CaptureException(err500)
-			}
-
-		})
+	if opts.ValidateOnCreate {
+		if errs := ValidateSentry500Options(opts); len(errs) > 0 {
+			panic(mdlwrsentry.FormatOptionErrors("MiddlewareSentry500", errs))
+		}
 	}
+	return mdlwrsentry.Middleware500(opts.middlewareOptions())
 }
 
-// statusCaptureResponseWriter is a custom response writer to capture the status code.
-type statusCaptureResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
-	body       string
-}
-
-// WriteHeader captures the status code before it's written.
-func (sw *statusCaptureResponseWriter) WriteHeader(code int) {
-	sw.statusCode = code
-	sw.ResponseWriter.WriteHeader(code)
-}
-
-// Write captures the body before it's written.
-func (sw *statusCaptureResponseWriter) Write(b []byte) (int, error) {
-	sw.body = string(b)
-	return sw.ResponseWriter.Write(b)
+// middlewareOptions returns the options of mdlwrsentry.Middleware500, the options are validated by MiddlewareSentry500.
+func (opts Sentry500Options) middlewareOptions() mdlwrsentry.Sentry500Options {
+	middlewareOpts := mdlwrsentry.Sentry500Options{
+		ExtractContext: opts.ExtractContext,
+		CaptureOptions: opts.CaptureOptions,
+		CaptureSource:  mdlwrsentry.CaptureSourceGoaMiddleware500,
+	}
+	if opts.DecodeGoaErrors {
+		middlewareOpts.DecodeErrorBody = decodeGoaErrorBody
+	}
+	if opts.IncludeFrameworkContext {
+		middlewareOpts.FrameworkContext = func(scope *sentry.Scope, r *http.Request) {
+			SetGoaContext(scope, r, "")
+		}
+	}
+	return middlewareOpts
 }
@@ -0,0 +1,499 @@
+package mdlwrsentrygoa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+// serve500 runs the middleware around a handler that fails with the given content type and body,
+// and returns the error that was sent to Sentry.
+func serve500(t *testing.T, opts Sentry500Options, contentType string, body string) mdlwrsentry.SentryError500 {
+	t.Helper()
+	hub, transport := sentrytest.NewRecordingHub(t)
+
+	var captured mdlwrsentry.SentryError500
+	opts.FingerprintOpts.Fingerprinters = []mdlwrsentry.Fingerprint{func(err error, _ []string) ([]string, error) {
+		captured = err.(mdlwrsentry.SentryError500) //nolint:errorlint
+		return nil, nil
+	}}
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(body))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/files/1", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	return captured
+}
+
+func TestMiddlewareSentry500SkipContentTypes(t *testing.T) {
+	for _, contentType := range []string{"image/png", "audio/ogg", "video/mp4", "application/octet-stream"} {
+		if err500 := serve500(t, DefaultSentry500Opts, contentType, "not text"); err500.Body != "" {
+			t.Errorf("expected %s body to be skipped, got %q", contentType, err500.Body)
+		}
+	}
+	if err500 := serve500(t, DefaultSentry500Opts, "application/json", `{"error":"boom"}`); err500.Body != `{"error":"boom"}` {
+		t.Errorf("unexpected body %q", err500.Body)
+	}
+}
+
+func TestMiddlewareSentry500DecodeGoaErrors(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.DecodeGoaErrors = true
+	body := `{"name":"db_down","id":"a1","message":"database unavailable"}`
+	if err500 := serve500(t, opts, "application/json", body); err500.ErrorName != "db_down" {
+		t.Errorf("unexpected error name %q", err500.ErrorName)
+	}
+	// the body is decoded before it is truncated
+	opts.MaxBodyBytes = 20
+	if err500 := serve500(t, opts, "application/json", body); err500.ErrorName != "db_down" || len(err500.Body) > 20 {
+		t.Errorf("unexpected error name %q or body %q", err500.ErrorName, err500.Body)
+	}
+	// past the decode limit the body is not decoded
+	long := `{"name":"db_down","message":"` + strings.Repeat("x", 64*1024) + `"}`
+	if err500 := serve500(t, opts, "application/json", long); err500.ErrorName != "" {
+		t.Errorf("expected a truncated body not to be decoded, got %q", err500.ErrorName)
+	}
+}
+
+func TestMiddlewareSentry500CaptureAsMessage(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.CaptureAsMessage = []int{http.StatusUnprocessableEntity}
+	for _, status := range []int{http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusBadRequest} {
+		hub, transport := sentrytest.NewRecordingHub(t)
+		handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/forms/3", nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		switch status {
+		case http.StatusUnprocessableEntity:
+			if len(transport.Events()) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+			}
+			event := transport.Events()[0]
+			if event.Message != "HTTP 422: /forms/3" || event.Level != sentry.LevelWarning || len(event.Exception) != 0 {
+				t.Errorf("unexpected message event %q %s %v", event.Message, event.Level, event.Exception)
+			}
+		case http.StatusInternalServerError:
+			if len(transport.Events()) != 1 || len(transport.Events()[0].Exception) == 0 {
+				t.Errorf("expected an exception event %v", transport.Events())
+			}
+		default:
+			if len(transport.Events()) != 0 {
+				t.Errorf("expected no event for %d", status)
+			}
+		}
+	}
+}
+
+func TestMiddlewareSentry500PreservesCORSHeaders(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	cors := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := cors(MiddlewareSentry500(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/cors", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusInternalServerError || len(transport.Events()) != 1 {
+		t.Fatalf("expected a reported 500, got %d with %d events", recorder.Code, len(transport.Events()))
+	}
+	if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "https://app.example.com" {
+		t.Errorf("CORS origin header lost %q", origin)
+	}
+	if creds := recorder.Header().Get("Access-Control-Allow-Credentials"); creds != "true" {
+		t.Errorf("CORS credentials header lost %q", creds)
+	}
+}
+
+func TestMiddlewareSentry500MeasureResponseSize(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.MeasureResponseSize = true
+	for _, status := range []int{http.StatusOK, http.StatusInternalServerError} {
+		hub, _ := sentrytest.NewRecordingHub(t)
+		handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte("hello "))
+			w.Write([]byte("world"))
+		}))
+		ctx := sentry.SetHubOnContext(context.Background(), hub)
+		span := sentry.StartSpan(ctx, "http.server")
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(span.Context())
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		span.Finish()
+
+		if size := span.Data["response_body_size"]; size != int64(11) {
+			t.Errorf("%d: unexpected size %v", status, size)
+		}
+	}
+}
+
+func TestMiddlewareSentry500ExtractContextPanic(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.ExtractContext = func(ctx context.Context, scope *sentry.Scope) {
+		scope.SetTag("set", "before panic")
+		_ = ctx.Value("user").(string)
+	}
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 || transport.Events()[0].Tags["set"] != "before panic" {
+		t.Fatalf("unexpected events %v", transport.Events())
+	}
+}
+
+func TestMiddlewareSentry500MethodLevelMap(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.MethodLevelMap = map[string]sentry.Level{http.MethodDelete: sentry.LevelFatal, http.MethodGet: sentry.LevelWarning}
+	opts.CaptureAsMessage = []int{http.StatusUnprocessableEntity}
+	tests := []struct {
+		method string
+		status int
+		want   sentry.Level
+	}{
+		{http.MethodDelete, http.StatusInternalServerError, sentry.LevelFatal},
+		{http.MethodGet, http.StatusInternalServerError, sentry.LevelWarning},
+		{http.MethodPut, http.StatusInternalServerError, sentry.LevelError},
+		{http.MethodDelete, http.StatusUnprocessableEntity, sentry.LevelWarning},
+	}
+	for _, tt := range tests {
+		hub, transport := sentrytest.NewRecordingHub(t)
+		handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		req := httptest.NewRequest(tt.method, "/users/1", nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if len(transport.Events()) != 1 || transport.Events()[0].Level != tt.want {
+			t.Errorf("%s %d: unexpected events %v", tt.method, tt.status, transport.Events())
+		}
+	}
+}
+
+func TestMiddlewareSentry500ChunkedResponse(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	var captured mdlwrsentry.SentryError500
+	opts := DefaultSentry500Opts
+	opts.FingerprintOpts.Fingerprinters = []mdlwrsentry.Fingerprint{func(err error, _ []string) ([]string, error) {
+		captured = err.(mdlwrsentry.SentryError500) //nolint:errorlint
+		return nil, nil
+	}}
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Transfer-Encoding", "chunked")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("row 1\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("row 2\n"))
+		w.(http.Flusher).Flush()
+		// the export fails here, the rest of the rows are never sent
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	if transport.Events()[0].Extra["response_encoding"] != "chunked" {
+		t.Errorf("unexpected extra %v", transport.Events()[0].Extra)
+	}
+	if captured.Body != "row 1\nrow 2\n[chunked-partial]" {
+		t.Errorf("unexpected body %q", captured.Body)
+	}
+}
+
+func TestMiddlewareSentry500SuppressSentryCapture(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentry500(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flags/unknown" {
+			mdlwrsentry.SuppressSentryCapture(r.Context())
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	for _, path := range []string{"/flags/unknown", "/flags/known"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(transport.Events()) != 1 || transport.Events()[0].Request.URL != "http://example.com/flags/known" {
+		t.Errorf("expected only the unsuppressed request to be captured %v", transport.Events())
+	}
+}
+
+func TestMiddlewareSentry500CaptureRequestReplay(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.CaptureRequestReplay = true
+	opts.CaptureRequestBody = true
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader(`{"name":"Ada"}`))
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	replay, ok := transport.Events()[0].Extra[mdlwrsentry.ReplayExtraKey].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a replay extra %v", transport.Events()[0].Extra)
+	}
+	curl, err := mdlwrsentry.ReplayToCurl(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if curl != `curl -X 'PUT' 'http://example.com/users/1' --data-raw '{"name":"Ada"}'` {
+		t.Errorf("unexpected command %s", curl)
+	}
+}
+
+func TestMiddlewareSentry500IncludeFrameworkContext(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.IncludeFrameworkContext = true
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	framework := transport.Events()[0].Contexts[mdlwrsentry.FrameworkContextKey]
+	if framework["name"] != "goa" || framework["version"] != "unknown" || framework["route"] != "/users/-omitted-" {
+		t.Errorf("unexpected framework context %v", framework)
+	}
+	if _, ok := framework["endpoint"]; ok {
+		t.Errorf("expected no endpoint %v", framework)
+	}
+
+	scope := sentry.NewScope()
+	SetGoaContext(scope, req, "users.show")
+	event := scope.ApplyToEvent(&sentry.Event{}, nil, nil)
+	if event.Contexts[mdlwrsentry.FrameworkContextKey]["endpoint"] != "users.show" {
+		t.Errorf("unexpected framework context %v", event.Contexts)
+	}
+}
+
+func TestMiddlewareSentry500SampleFunc(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.SampleFunc = mdlwrsentry.TypeBasedSampler([]mdlwrsentry.TypeSampleRule{{TypePattern: "Timeout", Rate: 0}})
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			mdlwrsentry.RecordRequestError(r.Context(), context.DeadlineExceeded)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	for _, path := range []string{"/slow", "/broken"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(transport.Events()) != 1 || transport.Events()[0].Request.URL != "http://example.com/broken" {
+		t.Errorf("expected the timeout to be sampled out %v", transport.Events())
+	}
+}
+
+func TestMiddlewareSentry500SlowRequestCapture(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.SlowRequestCapture = &mdlwrsentry.SlowRequestOpts{Threshold: 20 * time.Millisecond}
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/reports/7" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, path := range []string{"/reports/7", "/reports/8"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if event.Level != sentry.LevelWarning || !strings.HasPrefix(event.Message, "Slow request: ") ||
+		!strings.HasSuffix(event.Message, " on /reports/7") {
+		t.Errorf("unexpected event %s %q", event.Level, event.Message)
+	}
+	if !slices.Equal(event.Fingerprint, []string{"slow-request", "/reports/-omitted-"}) {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+}
+
+func TestMiddlewareSentry500RequestData(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.CaptureRequestBody = true
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=ada&token=abc"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	if data := transport.Events()[0].Request.Data; data != "token=[Filtered]&user=ada" {
+		t.Errorf("unexpected request data %q", data)
+	}
+}
+
+func TestMiddlewareSentry500RequestID(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	sentry500 := MiddlewareSentry500(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler := mdlwrsentry.RequestIDMiddleware(func() string { return "req-1" })(sentry500)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 || transport.Events()[0].Tags[mdlwrsentry.RequestIDTag] != "req-1" {
+		t.Errorf("expected the generated request id tag %v", transport.Events())
+	}
+}
+
+func TestMiddlewareSentry500CaptureTimeout(t *testing.T) {
+	received := make(chan struct{}, 1)
+	slowSentry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		received <- struct{}{}
+	}))
+	defer slowSentry.Close()
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       strings.Replace(slowSentry.URL, "http://", "http://key@", 1) + "/1",
+		Transport: sentry.NewHTTPSyncTransport(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	opts := DefaultSentry500Opts
+	opts.CaptureTimeout = 20 * time.Millisecond
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	start := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected the response not to wait for Sentry, took %s", elapsed)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the event to be sent in the background")
+	}
+}
+
+func TestMiddlewareSentry500DefaultSeverity(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.DefaultSeverity = sentry.LevelFatal
+	opts.MethodLevelMap = map[string]sentry.Level{http.MethodGet: sentry.LevelWarning}
+	handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 || transport.Events()[0].Level != sentry.LevelFatal {
+		t.Errorf("expected the severity to be applied %v", transport.Events())
+	}
+}
+
+func TestMiddlewareSentry500ScopeIsolation(t *testing.T) {
+	for _, captureTimeout := range []time.Duration{0, time.Minute} {
+		t.Run(fmt.Sprint("CaptureTimeout=", captureTimeout), func(t *testing.T) {
+			hub, transport := sentrytest.NewRecordingHub(t)
+			opts := DefaultSentry500Opts
+			opts.CaptureTimeout = captureTimeout
+			opts.ExtractContext = func(ctx context.Context, scope *sentry.Scope) {
+				scope.SetUser(sentry.User{ID: mdlwrsentry.RequestFromContext(ctx).URL.Query().Get("user")})
+			}
+			handler := MiddlewareSentry500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			const requests = 50
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func(user string) {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodGet, "/orders?user="+user, nil)
+					req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+					handler.ServeHTTP(httptest.NewRecorder(), req)
+				}(fmt.Sprint(i))
+			}
+			wg.Wait()
+
+			if len(transport.Events()) != requests {
+				t.Fatalf("expected %d events, got %d", requests, len(transport.Events()))
+			}
+			for _, event := range transport.Events() {
+				if want := "user=" + event.User.ID; event.Request.QueryString != want {
+					t.Errorf("event of %q has the user of another request %q", event.Request.QueryString, event.User.ID)
+				}
+			}
+			if user := sentrytest.CapturedScope(hub).User(); user.ID != "" {
+				t.Errorf("the shared hub scope got the user %q", user.ID)
+			}
+		})
+	}
+}
+
+func TestGoaFilterErrorTypeRegistered(t *testing.T) {
+	if !slices.Contains(mdlwrsentry.FilterErrorTypes(), FilterErrorType) {
+		t.Errorf("expected goa error types to be filtered %v", mdlwrsentry.FilterErrorTypes())
+	}
+}
@@ -0,0 +1,50 @@
+package mdlwrsentrygoa
+
+import (
+	"net/http"
+	"time"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+)
+
+// MiddlewareSentrySlowRequest sends a warning to Sentry for requests slower than threshold.
+// A slow request that ends in a 500 is not reported here:
+// MiddlewareSentry500 adds a slow request breadcrumb to its event instead, so put this middleware before it in the chain.
+// The hub of the request context is preferred over the given hub.
+func MiddlewareSentrySlowRequest(threshold time.Duration, hub *sentry.Hub) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timing := &mdlwrsentry.RequestTiming{Start: time.Now(), Threshold: threshold}
+			r = r.WithContext(mdlwrsentry.ContextWithRequestTiming(r.Context(), timing))
+			recorder, status := mdlwrsentry.StatusRecorder(w)
+
+			next.ServeHTTP(recorder, r)
+
+			elapsed := timing.Elapsed()
+			if elapsed <= threshold || status() == 500 {
+				return
+			}
+			reportHub := sentry.GetHubFromContext(r.Context())
+			if reportHub == nil {
+				reportHub = hub
+			}
+			if reportHub == nil {
+				reportHub = sentry.CurrentHub()
+			}
+			urlStr := ""
+			if url := r.URL; url != nil {
+				urlStr = url.String()
+			}
+
+			event := sentry.NewEvent()
+			event.Level = sentry.LevelWarning
+			event.Message = "slow request " + r.Method + " " + urlStr + " took " + elapsed.String()
+			event.Request = sentry.NewRequest(r)
+			event.Fingerprint = []string{"slow_request", r.Method, mdlwrsentry.NormalizeUrlPathForSentry(r.URL, "")}
+			event.Extra["elapsed_ms"] = elapsed.Milliseconds()
+			event.Extra["threshold_ms"] = threshold.Milliseconds()
+			reportHub.CaptureEvent(event)
+		})
+	}
+}
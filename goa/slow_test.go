@@ -0,0 +1,53 @@
+package mdlwrsentrygoa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func slowHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(status)
+	})
+}
+
+func TestMiddlewareSentrySlowRequest(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentrySlowRequest(5*time.Millisecond, hub)(slowHandler(http.StatusOK))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports/9", nil))
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if event.Level != sentry.LevelWarning || event.Fingerprint[2] != "/reports/-omitted-" {
+		t.Errorf("unexpected event %s %v", event.Level, event.Fingerprint)
+	}
+}
+
+func TestMiddlewareSentrySlowRequest500(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentrySlowRequest(5*time.Millisecond, hub)(
+		MiddlewareSentry500(DefaultSentry500Opts)(slowHandler(http.StatusInternalServerError)),
+	)
+	req := httptest.NewRequest(http.MethodGet, "/reports/9", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected only the 500 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if len(event.Exception) == 0 {
+		t.Errorf("expected an exception event")
+	}
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Category != "slow_request" {
+		t.Errorf("expected a slow request breadcrumb %v", event.Breadcrumbs)
+	}
+}
@@ -0,0 +1,19 @@
+package mdlwrsentrygoa
+
+import (
+	"net/http"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+)
+
+// TimeoutOptions is mdlwrsentry.TimeoutOptions.
+//
+// Deprecated: use mdlwrsentry.TimeoutOptions.
+type TimeoutOptions = mdlwrsentry.TimeoutOptions
+
+// MiddlewareSentryTimeout is mdlwrsentry.MiddlewareSentryTimeout.
+//
+// Deprecated: use mdlwrsentry.MiddlewareSentryTimeout, it does not depend on Goa.
+func MiddlewareSentryTimeout(opts TimeoutOptions) func(http.Handler) http.Handler {
+	return mdlwrsentry.MiddlewareSentryTimeout(opts)
+}
@@ -0,0 +1,10 @@
+package mdlwrsentrygoa
+
+import (
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+)
+
+// ValidateSentry500Options returns all the problems of the options, so that they can be fixed at once.
+func ValidateSentry500Options(opts Sentry500Options) []error {
+	return mdlwrsentry.ValidateSentry500Options(opts.middlewareOptions())
+}
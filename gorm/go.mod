@@ -0,0 +1,15 @@
+module github.com/digitalmint/go-sentry-middleware/gorm
+
+go 1.22.0
+
+require (
+	github.com/getsentry/sentry-go v0.31.1
+	gorm.io/gorm v1.25.12
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
@@ -0,0 +1,86 @@
+package sentrygorm
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/getsentry/sentry-go"
+	"gorm.io/gorm"
+)
+
+const spanInstanceKey = "sentry:span"
+
+// numberedPlaceholder matches the $1 style bind parameters of postgres
+var numberedPlaceholder = regexp.MustCompile(`\$[0-9]+`)
+
+type plugin struct{}
+
+// Plugin returns a GORM plugin that records each query as a "db.sql" span of the active transaction,
+// the span of the statement context set with db.WithContext.
+// Statements run outside of a transaction are not recorded.
+func Plugin() gorm.Plugin {
+	return plugin{}
+}
+
+func (p plugin) Name() string {
+	return "sentry"
+}
+
+type registerer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (p plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	operations := []struct {
+		name          string
+		before, after registerer
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	var errs []error
+	for _, op := range operations {
+		errs = append(errs,
+			op.before.Register("sentry:before_"+op.name, startSpan),
+			op.after.Register("sentry:after_"+op.name, finishSpan),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+func startSpan(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		return
+	}
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return
+	}
+	span := parent.StartChild("db.sql")
+	db.InstanceSet(spanInstanceKey, span)
+}
+
+// finishSpan runs after the statement, when the SQL has been built.
+func finishSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanInstanceKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(*sentry.Span)
+	if !ok {
+		return
+	}
+	span.Description = numberedPlaceholder.ReplaceAllString(db.Statement.SQL.String(), "?")
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.Status = sentry.SpanStatusInternalError
+	} else {
+		span.Status = sentry.SpanStatusOK
+	}
+	span.Finish()
+}
@@ -0,0 +1,73 @@
+package sentrygorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type spanRecorder struct {
+	events []*sentry.Event
+}
+
+func (sr *spanRecorder) SendEvent(event *sentry.Event)    { sr.events = append(sr.events, event) }
+func (sr *spanRecorder) Configure(_ sentry.ClientOptions) {}
+func (sr *spanRecorder) Flush(_ time.Duration) bool       { return true }
+func (sr *spanRecorder) Close()                           {}
+
+type order struct {
+	ID     uint
+	Status string
+}
+
+// openDryRun opens a database with a dialector that builds the SQL without running it.
+func openDryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(Plugin()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPlugin(t *testing.T) {
+	recorder := &spanRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn: "https://key@o1.ingest.sentry.io/1", Transport: recorder, EnableTracing: true, TracesSampleRate: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+	db := openDryRun(t)
+
+	transaction := sentry.StartTransaction(ctx, "orders")
+	db.WithContext(transaction.Context()).Where("status = ?", "paid").Find(&[]order{})
+	transaction.Finish()
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(recorder.events))
+	}
+	spans := recorder.events[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Op != "db.sql" || spans[0].Description != "SELECT * FROM `orders` WHERE status = ?" {
+		t.Errorf("unexpected span %s %q", spans[0].Op, spans[0].Description)
+	}
+}
+
+func TestPluginWithoutTransaction(t *testing.T) {
+	db := openDryRun(t)
+	stmt := db.WithContext(context.Background()).Find(&[]order{})
+	if _, ok := stmt.InstanceGet(spanInstanceKey); ok {
+		t.Errorf("expected no span outside of a transaction")
+	}
+}
@@ -0,0 +1,113 @@
+package sentry
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type GoroutineWatcherOpts struct {
+	// PollInterval defaults to 10 seconds
+	PollInterval time.Duration
+	// Threshold is the goroutine count that is reported, 0 disables it
+	Threshold int
+	// GrowthRatePct is the growth between two samples that is reported, e.g. 50 for +50%, 0 disables it
+	GrowthRatePct float64
+	// MaxStackBytes is the size of the goroutine dump sent with the event, defaults to 64KB
+	MaxStackBytes int
+	// CooldownPeriod is the minimum time between two events
+	CooldownPeriod time.Duration
+}
+
+var DefaultGoroutineWatcherOpts = GoroutineWatcherOpts{
+	PollInterval:   10 * time.Second,
+	Threshold:      10000,
+	GrowthRatePct:  100,
+	MaxStackBytes:  64 * 1024,
+	CooldownPeriod: 10 * time.Minute,
+}
+
+var ErrWatcherStopped = errors.New("watcher already stopped")
+
+// GoroutineWatcher reports goroutine count spikes, see WatchGoroutineCount.
+type GoroutineWatcher struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchGoroutineCount samples runtime.NumGoroutine() in a goroutine and sends a warning with a goroutine dump
+// when the count is over the threshold or grew too fast since the previous sample.
+func WatchGoroutineCount(hub *sentry.Hub, opts GoroutineWatcherOpts) *GoroutineWatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultGoroutineWatcherOpts.PollInterval
+	}
+	if opts.MaxStackBytes <= 0 {
+		opts.MaxStackBytes = DefaultGoroutineWatcherOpts.MaxStackBytes
+	}
+	w := &GoroutineWatcher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(opts.PollInterval)
+		defer ticker.Stop()
+
+		previous := runtime.NumGoroutine()
+		var lastSent time.Time
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			count := runtime.NumGoroutine()
+			growthPct := float64(count-previous) / float64(previous) * 100
+			exceeded := (opts.Threshold > 0 && count > opts.Threshold) ||
+				(opts.GrowthRatePct > 0 && growthPct > opts.GrowthRatePct)
+			if exceeded && (lastSent.IsZero() || time.Since(lastSent) >= opts.CooldownPeriod) {
+				lastSent = time.Now()
+				captureGoroutineSpike(hub, count, previous, opts.MaxStackBytes)
+			}
+			previous = count
+		}
+	}()
+	return w
+}
+
+func captureGoroutineSpike(hub *sentry.Hub, count, previous, maxStackBytes int) {
+	stack := make([]byte, maxStackBytes)
+	stack = stack[:runtime.Stack(stack, true)]
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelWarning
+	event.Message = "goroutine count " + strconv.Itoa(count)
+	event.Fingerprint = []string{"goroutine_spike"}
+	event.Extra = map[string]interface{}{
+		"goroutine_count": count,
+		"previous_count":  previous,
+		"goroutine_dump":  string(stack),
+		"dump_truncated":  len(stack) == maxStackBytes,
+	}
+	hub.CaptureEvent(event)
+}
+
+// Stop stops sampling, use Wait to wait for the goroutine to exit.
+func (w *GoroutineWatcher) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.stop:
+		return ErrWatcherStopped
+	default:
+		close(w.stop)
+		return nil
+	}
+}
+
+// Wait blocks until the watcher is stopped.
+func (w *GoroutineWatcher) Wait() {
+	<-w.done
+}
@@ -0,0 +1,54 @@
+package sentry
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+)
+
+func TestWatchGoroutineCount(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	w := WatchGoroutineCount(hub, GoroutineWatcherOpts{
+		PollInterval:   time.Millisecond,
+		Threshold:      runtime.NumGoroutine() + 50,
+		MaxStackBytes:  4096,
+		CooldownPeriod: time.Hour,
+	})
+
+	release := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		go func() { <-release }()
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		sent := len(transport.Events())
+		if sent > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	w.Wait()
+	if err := w.Stop(); !errors.Is(err, ErrWatcherStopped) {
+		t.Errorf("unexpected %v", err)
+	}
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	extra := transport.Events()[0].Extra
+	if count, _ := extra["goroutine_count"].(int); count < 100 {
+		t.Errorf("unexpected count %v", extra["goroutine_count"])
+	}
+	dump, _ := extra["goroutine_dump"].(string)
+	if len(dump) > 4096 || !strings.HasPrefix(dump, "goroutine ") {
+		t.Errorf("unexpected dump %q", dump)
+	}
+}
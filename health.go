@@ -0,0 +1,117 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// HealthCheckTag marks the events sent by SentryHealthHandler.
+const HealthCheckTag = "health_check"
+
+// SentryHealthHandler answers GET requests with {"sentry": "ok"} when a debug event tagged health_check
+// is accepted by Sentry within timeout, and with 503 {"sentry": "unreachable"} otherwise.
+//
+// The event is sent synchronously with a client copied from the hub client when the handler is created,
+// so the response of Sentry is known. That client does not run the BeforeSend of the hub client: dropping the
+// event before it is sent would make the check always pass. Add DropHealthCheckEvents to the BeforeSend of
+// the application clients to keep health check events that reach them out of the project.
+func SentryHealthHandler(hub *sentry.Hub, timeout time.Duration) http.Handler {
+	check := newHealthCheck(hub, timeout)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !check.sentryReachable() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"sentry": "unreachable"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"sentry": "ok"})
+	})
+}
+
+// healthCheck sends the health check events, client is nil when the hub has no usable client.
+type healthCheck struct {
+	client   *sentry.Client
+	recorder *statusRecordingTransport
+	timeout  time.Duration
+	// mu serializes the checks so that the recorded status is the one of the current check
+	mu sync.Mutex
+}
+
+func newHealthCheck(hub *sentry.Hub, timeout time.Duration) *healthCheck {
+	check := &healthCheck{timeout: timeout}
+	clientOld := hub.Client()
+	if clientOld == nil {
+		return check
+	}
+	options := clientOld.Options()
+	rt := options.HTTPTransport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	recorder := &statusRecordingTransport{RT: rt}
+	transport := sentry.NewHTTPSyncTransport()
+	transport.Timeout = timeout
+	options.Transport = transport
+	options.HTTPTransport = recorder
+	options.BeforeSend = nil
+	options.SampleRate = 1
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		return check
+	}
+	check.client = client
+	check.recorder = recorder
+	return check
+}
+
+func (hc *healthCheck) sentryReachable() bool {
+	if hc.client == nil {
+		return false
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.recorder.status.Store(0)
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelDebug
+	event.Message = "Sentry health check"
+	event.Tags = map[string]string{HealthCheckTag: "true"}
+	hc.client.CaptureEvent(event, nil, nil)
+	if !hc.client.Flush(hc.timeout) {
+		return false
+	}
+	status := hc.recorder.status.Load()
+	return status >= 200 && status < 300
+}
+
+// statusRecordingTransport records the status code of the last response.
+type statusRecordingTransport struct {
+	RT     http.RoundTripper
+	status atomic.Int64
+}
+
+func (t *statusRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RT.RoundTrip(req)
+	if resp != nil {
+		t.status.Store(int64(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// DropHealthCheckEvents is a BeforeSend that drops the events sent by SentryHealthHandler.
+func DropHealthCheckEvents(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if event.Tags[HealthCheckTag] == "true" {
+		return nil
+	}
+	return event
+}
@@ -0,0 +1,52 @@
+package sentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestSentryHealthHandler(t *testing.T) {
+	server := sentrytest.NewServer(t)
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: server.DSN(), BeforeSend: DropHealthCheckEvents})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	handler := SentryHealthHandler(hub, time.Second)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/sentry", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"sentry":"ok"}` {
+		t.Errorf("unexpected %d %s", rec.Code, rec.Body)
+	}
+	// the client of the check is reused
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/sentry", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("unexpected %d %s", rec.Code, rec.Body)
+	}
+	events := server.Events()
+	if len(events) != 2 || events[0].Level != sentry.LevelDebug || events[0].Tags[HealthCheckTag] != "true" {
+		t.Errorf("unexpected events %v", events)
+	}
+
+	// the application client drops health check events
+	hub.CaptureEvent(&sentry.Event{Message: "check", Tags: map[string]string{HealthCheckTag: "true"}})
+	hub.Flush(time.Second)
+	if len(server.Events()) != 2 {
+		t.Errorf("the health check event was not dropped")
+	}
+
+	server.Close()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/sentry", nil))
+	if rec.Code != http.StatusServiceUnavailable || strings.TrimSpace(rec.Body.String()) != `{"sentry":"unreachable"}` {
+		t.Errorf("unexpected %d %s", rec.Code, rec.Body)
+	}
+}
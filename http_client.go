@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// WrapHTTPClientOpts selects the transports applied by WrapHTTPClient.
+type WrapHTTPClientOpts struct {
+	// DisableCapture does not report failed requests with a CapturingTransport
+	DisableCapture bool
+	// DisableTrace does not propagate the trace with a SentryTraceTransport
+	DisableTrace  bool
+	CapturingOpts CapturingTransportOpts
+}
+
+var DefaultWrapHTTPClientOpts = WrapHTTPClientOpts{
+	CapturingOpts: DefaultCapturingTransportOpts,
+}
+
+// WrapHTTPClient returns a copy of client, nil means http.DefaultClient, whose transport reports failed requests
+// to hub and propagates the trace: CapturingTransport then SentryTraceTransport then the transport of client,
+// so that the span of a request that fails is finished before the event is captured.
+// The hub of the request context is used first, see CapturingTransport.
+func WrapHTTPClient(client *http.Client, hub *sentry.Hub, opts WrapHTTPClientOpts) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if !opts.DisableTrace {
+		rt = NewSentryTraceTransport(rt)
+	}
+	if !opts.DisableCapture {
+		rt = NewCapturingTransport(rt, hub, opts.CapturingOpts)
+	}
+	wrapped.Transport = rt
+	return &wrapped
+}
@@ -0,0 +1,56 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWrapHTTPClient(t *testing.T) {
+	var traceHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get(sentry.SentryTraceHeader)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	hub, transport := newTracingHub(t)
+	client := &http.Client{Timeout: time.Minute}
+	wrapped := WrapHTTPClient(client, hub, DefaultWrapHTTPClientOpts)
+	if client.Transport != nil || wrapped.Timeout != time.Minute {
+		t.Errorf("expected a copy of the client with only the transport replaced")
+	}
+
+	tx := sentry.StartTransaction(sentry.SetHubOnContext(context.Background(), hub), "job")
+	req, err := http.NewRequestWithContext(tx.Context(), http.MethodGet, ts.URL+"/orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := wrapped.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	tx.Finish()
+
+	if traceHeader == "" {
+		t.Error("expected the trace to be propagated")
+	}
+	if len(transport.Events()) != 2 || len(transport.Events()[0].Exception) == 0 || transport.Events()[1].Type != "transaction" {
+		t.Fatalf("expected the failed request and the transaction %v", transport.Events())
+	}
+	if len(transport.Events()[1].Spans) != 1 {
+		t.Errorf("expected the http.client span %v", transport.Events()[1].Spans)
+	}
+}
+
+func TestWrapHTTPClientDisabled(t *testing.T) {
+	wrapped := WrapHTTPClient(nil, nil, WrapHTTPClientOpts{DisableCapture: true, DisableTrace: true})
+	if wrapped == http.DefaultClient || wrapped.Transport != http.DefaultTransport {
+		t.Errorf("expected a copy of the default client with the default transport %v", wrapped.Transport)
+	}
+}
@@ -0,0 +1,131 @@
+package sentry
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// HubModifier adds request data to the hub before the middleware captures an event.
+// Unlike an ExtractContext callback, a modifier can be tested on its own against a hub.
+type HubModifier interface {
+	ModifyHub(ctx context.Context, hub *sentry.Hub)
+}
+
+// HubModifierFunc adapts a function to a HubModifier.
+type HubModifierFunc func(ctx context.Context, hub *sentry.Hub)
+
+func (f HubModifierFunc) ModifyHub(ctx context.Context, hub *sentry.Hub) {
+	f(ctx, hub)
+}
+
+// ApplyHubModifiers applies the modifiers in order.
+// A modifier that panics is logged and skipped, the scope keeps what it set before the panic.
+func ApplyHubModifiers(ctx context.Context, hub *sentry.Hub, modifiers []HubModifier) {
+	for _, modifier := range modifiers {
+		RunRecovered("HubModifier", func() { modifier.ModifyHub(ctx, hub) })
+	}
+}
+
+// RunRecovered calls a user callback of a middleware and logs a panic instead of propagating it,
+// so that a bug in the callback does not prevent the error from being reported.
+func RunRecovered(name string, fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			slog.Error("panic in "+name, "panic", v, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
+
+// ContextWithRequest makes the request available to HubModifiers through RequestFromContext.
+// The middlewares do this before applying the modifiers.
+func ContextWithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey, r)
+}
+
+// RequestFromContext returns the request stored by ContextWithRequest or nil.
+func RequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey).(*http.Request)
+	return r
+}
+
+// WithSentryTags stores tags that ContextTagsModifier sets on the events of the request,
+// for example tags forwarded by an API gateway. Tags are merged with those already in the context,
+// the new value wins for the same key.
+func WithSentryTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for key, value := range SentryTagsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, tagsKey, merged)
+}
+
+// SentryTagsFromContext returns the tags stored by WithSentryTags, the map must not be modified.
+func SentryTagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	return tags
+}
+
+// ContextTagsModifier sets the tags stored with WithSentryTags. The middlewares apply it before the HubModifiers.
+var ContextTagsModifier HubModifier = HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+	if tags := SentryTagsFromContext(ctx); len(tags) > 0 {
+		hub.Scope().SetTags(tags)
+	}
+})
+
+// LegacyExtractContextModifier adapts an ExtractContext callback to a HubModifier.
+func LegacyExtractContextModifier(fn func(context.Context, *sentry.Scope)) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		fn(ctx, hub.Scope())
+	})
+}
+
+// RequestTagModifier tags the event with the given request headers, for example a request id.
+// The tag key is the canonical header name. Missing headers are not tagged.
+func RequestTagModifier(headers []string) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		r := RequestFromContext(ctx)
+		if r == nil {
+			return
+		}
+		for _, header := range headers {
+			if value := r.Header.Get(header); value != "" {
+				hub.Scope().SetTag(http.CanonicalHeaderKey(header), value)
+			}
+		}
+	})
+}
+
+// UserExtractor returns the user of the request, ok is false when there is none.
+type UserExtractor func(ctx context.Context) (user sentry.User, ok bool)
+
+// UserContextModifier sets the user returned by the extractor.
+func UserContextModifier(extractor UserExtractor) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		if user, ok := extractor(ctx); ok {
+			hub.Scope().SetUser(user)
+		}
+	})
+}
+
+// TransactionModifier sets the event transaction, usually the route or the operation name.
+// An empty name leaves the transaction unchanged.
+func TransactionModifier(extractor func(context.Context) string) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		name := extractor(ctx)
+		if name == "" {
+			return
+		}
+		hub.Scope().AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			event.Transaction = name
+			return event
+		})
+	})
+}
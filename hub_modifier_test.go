@@ -0,0 +1,76 @@
+package sentry
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestHubModifiers(t *testing.T) {
+	r := httptest.NewRequest("GET", "/orders/1", nil)
+	r.Header.Set("X-Request-Id", "req-123")
+	ctx := ContextWithRequest(context.Background(), r)
+
+	hub, transport := sentrytest.NewRecordingHub(t)
+	ApplyHubModifiers(ctx, hub, []HubModifier{
+		RequestTagModifier([]string{"x-request-id", "X-Missing"}),
+		UserContextModifier(func(context.Context) (sentry.User, bool) {
+			return sentry.User{ID: "42"}, true
+		}),
+		TransactionModifier(func(context.Context) string { return "GET /orders/{id}" }),
+		LegacyExtractContextModifier(func(_ context.Context, scope *sentry.Scope) {
+			scope.SetTag("legacy", "yes")
+		}),
+	})
+
+	recorder := sentrytest.CapturedScope(hub)
+	tags := recorder.Tags()
+	if tags["X-Request-Id"] != "req-123" || tags["legacy"] != "yes" || len(tags) != 2 {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if recorder.User().ID != "42" {
+		t.Errorf("unexpected user %v", recorder.User())
+	}
+
+	hub.CaptureMessage("with transaction")
+	if len(transport.Events()) != 1 || transport.Events()[0].Transaction != "GET /orders/{id}" {
+		t.Errorf("unexpected transaction %v", transport.Events())
+	}
+}
+
+func TestApplyHubModifiersRecoversPanics(t *testing.T) {
+	hub, _ := sentrytest.NewRecordingHub(t)
+	ApplyHubModifiers(context.Background(), hub, []HubModifier{
+		HubModifierFunc(func(_ context.Context, hub *sentry.Hub) {
+			hub.Scope().SetTag("before", "panic")
+			var user *sentry.User
+			hub.Scope().SetUser(*user)
+		}),
+		HubModifierFunc(func(_ context.Context, hub *sentry.Hub) {
+			hub.Scope().SetTag("after", "panic")
+		}),
+	})
+	tags := sentrytest.CapturedScope(hub).Tags()
+	if tags["before"] != "panic" || tags["after"] != "panic" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
+
+func TestWithSentryTags(t *testing.T) {
+	ctx := WithSentryTags(context.Background(), map[string]string{"region": "us-east-1", "tenant": "a"})
+	parent := ctx
+	ctx = WithSentryTags(ctx, map[string]string{"tenant": "b", "gateway": "edge-1"})
+
+	hub, _ := sentrytest.NewRecordingHub(t)
+	ContextTagsModifier.ModifyHub(ctx, hub)
+	tags := sentrytest.CapturedScope(hub).Tags()
+	if len(tags) != 3 || tags["region"] != "us-east-1" || tags["tenant"] != "b" || tags["gateway"] != "edge-1" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if SentryTagsFromContext(parent)["tenant"] != "a" {
+		t.Errorf("the parent context was modified %v", SentryTagsFromContext(parent))
+	}
+}
@@ -0,0 +1,72 @@
+// Package envelope reads the Sentry envelope format.
+// See: https://develop.sentry.dev/sdk/envelopes/
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Item is one item of an envelope, such as an event or an attachment.
+type Item struct {
+	Type    string
+	Payload []byte
+}
+
+type itemHeader struct {
+	Type   string `json:"type"`
+	Length int    `json:"length"`
+}
+
+// IsEnvelope reports whether body is an envelope rather than a single JSON event.
+// An envelope starts with a header line followed by at least one item header line.
+func IsEnvelope(body []byte) bool {
+	header, rest, found := bytes.Cut(bytes.TrimSpace(body), []byte("\n"))
+	if !found || !json.Valid(header) {
+		return false
+	}
+	itemLine, _, _ := bytes.Cut(rest, []byte("\n"))
+	item := itemHeader{}
+	return json.Unmarshal(itemLine, &item) == nil && item.Type != ""
+}
+
+// Decode returns the items of an envelope, skipping the envelope header.
+func Decode(r io.Reader) ([]Item, error) {
+	reader := bufio.NewReader(r)
+	if _, err := reader.ReadBytes('\n'); err != nil {
+		return nil, fmt.Errorf("reading envelope header: %w", err)
+	}
+
+	var items []Item
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if errors.Is(err, io.EOF) {
+				return items, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		header := itemHeader{}
+		if err := json.Unmarshal(line, &header); err != nil {
+			return nil, fmt.Errorf("reading item header: %w", err)
+		}
+
+		var payload []byte
+		if header.Length > 0 {
+			payload = make([]byte, header.Length)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return nil, fmt.Errorf("reading %s item: %w", header.Type, err)
+			}
+		} else if payload, err = reader.ReadBytes('\n'); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading %s item: %w", header.Type, err)
+		}
+		items = append(items, Item{Type: header.Type, Payload: bytes.TrimSpace(payload)})
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/digitalmint/go-sentry-middleware/jwt
+
+go 1.22.0
+
+require (
+	github.com/digitalmint/go-sentry-middleware v0.0.0
+	github.com/getsentry/sentry-go v0.31.1
+	github.com/golang-jwt/jwt/v5 v5.2.1
+)
+
+require (
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace github.com/digitalmint/go-sentry-middleware => ../
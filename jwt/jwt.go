@@ -0,0 +1,104 @@
+// Package sentryjwt sets the Sentry user from the claims of the JWT bearer token of the request.
+// It is a separate module so that golang-jwt is not a dependency of the middlewares.
+package sentryjwt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsMapping are the names of the JWT claims copied to the Sentry user, empty names are skipped.
+// Role is stored in the user data under "role".
+type ClaimsMapping struct {
+	UserID string
+	Email  string
+	Name   string
+	Role   string
+}
+
+// DefaultClaimsMapping uses the registered and OpenID Connect claim names.
+var DefaultClaimsMapping = ClaimsMapping{
+	UserID: "sub",
+	Email:  "email",
+	Name:   "name",
+	Role:   "role",
+}
+
+// JWTScopeExtractor sets the Sentry user from the claims of the bearer token of the request.
+// The signature is verified with publicKey when it is not nil, otherwise the token is only decoded:
+// the claims end up in an error report, not in an authorization decision.
+func JWTScopeExtractor(publicKey any, claimsMapping ClaimsMapping) mdlwrsentry.HubModifier {
+	if publicKey == nil {
+		return jwtScopeExtractor(nil, claimsMapping)
+	}
+	return JWTScopeExtractorWithVerification(func(*jwt.Token) (any, error) {
+		return publicKey, nil
+	}, claimsMapping)
+}
+
+// JWTScopeExtractorWithVerification is JWTScopeExtractor with the signature verified using keyFunc.
+// Tokens that fail verification do not set the user.
+func JWTScopeExtractorWithVerification(keyFunc jwt.Keyfunc, claimsMapping ClaimsMapping) mdlwrsentry.HubModifier {
+	if keyFunc == nil {
+		panic("JWTScopeExtractorWithVerification: nil keyFunc")
+	}
+	return jwtScopeExtractor(keyFunc, claimsMapping)
+}
+
+func jwtScopeExtractor(keyFunc jwt.Keyfunc, claimsMapping ClaimsMapping) mdlwrsentry.HubModifier {
+	return mdlwrsentry.HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		r := mdlwrsentry.RequestFromContext(ctx)
+		if r == nil {
+			return
+		}
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return
+		}
+		claims := jwt.MapClaims{}
+		var err error
+		if keyFunc == nil {
+			_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+		} else {
+			_, err = jwt.ParseWithClaims(token, claims, keyFunc)
+		}
+		if err != nil {
+			return
+		}
+		if user, ok := claimsMapping.user(claims); ok {
+			hub.Scope().SetUser(user)
+		}
+	})
+}
+
+func (m ClaimsMapping) user(claims jwt.MapClaims) (sentry.User, bool) {
+	claim := func(name string) string {
+		if name == "" || claims[name] == nil {
+			return ""
+		}
+		switch value := claims[name].(type) {
+		case string:
+			return value
+		case float64:
+			// JSON numbers, e.g. a numeric sub
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+	user := sentry.User{
+		ID:    claim(m.UserID),
+		Email: claim(m.Email),
+		Name:  claim(m.Name),
+	}
+	if role := claim(m.Role); role != "" {
+		user.Data = map[string]string{"role": role}
+	}
+	return user, !user.IsEmpty()
+}
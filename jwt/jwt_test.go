@@ -0,0 +1,54 @@
+package sentryjwt
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testJWT is signed with HS256 and the key "test-secret", claims:
+// {"email":"jane@example.com","name":"Jane Doe","role":"admin","sub":"user-42"}
+const testJWT = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+	"eyJlbWFpbCI6ImphbmVAZXhhbXBsZS5jb20iLCJuYW1lIjoiSmFuZSBEb2UiLCJyb2xlIjoiYWRtaW4iLCJzdWIiOiJ1c2VyLTQyIn0." +
+	"AZN3DCDmv6aT9UIUUeGgE2QAg8w-vR_n2OfGjudkorA"
+
+func TestJWTScopeExtractor(t *testing.T) {
+	hmacKey := func(key string) jwt.Keyfunc {
+		return func(*jwt.Token) (any, error) { return []byte(key), nil }
+	}
+	tests := []struct {
+		name          string
+		authorization string
+		modifier      mdlwrsentry.HubModifier
+		wantUserID    string
+	}{
+		{"unverified", "Bearer " + testJWT, JWTScopeExtractor(nil, DefaultClaimsMapping), "user-42"},
+		{"public key", "Bearer " + testJWT, JWTScopeExtractor([]byte("test-secret"), DefaultClaimsMapping), "user-42"},
+		{"verified", "bearer " + testJWT, JWTScopeExtractorWithVerification(hmacKey("test-secret"), DefaultClaimsMapping), "user-42"},
+		{"wrong key", "Bearer " + testJWT, JWTScopeExtractorWithVerification(hmacKey("other"), DefaultClaimsMapping), ""},
+		{"not bearer", "Basic dXNlcjpwYXNz", JWTScopeExtractor(nil, DefaultClaimsMapping), ""},
+		{"malformed", "Bearer not.a.jwt", JWTScopeExtractor(nil, DefaultClaimsMapping), ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", tt.authorization)
+		hub, _ := sentrytest.NewRecordingHub(t)
+		tt.modifier.ModifyHub(mdlwrsentry.ContextWithRequest(context.Background(), r), hub)
+		if user := sentrytest.CapturedScope(hub).User(); user.ID != tt.wantUserID {
+			t.Errorf("%s: unexpected user %v", tt.name, user)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+testJWT)
+	hub, _ := sentrytest.NewRecordingHub(t)
+	JWTScopeExtractor(nil, ClaimsMapping{UserID: "email", Role: "role"}).ModifyHub(mdlwrsentry.ContextWithRequest(context.Background(), r), hub)
+	user := sentrytest.CapturedScope(hub).User()
+	if user.ID != "jane@example.com" || user.Email != "" || user.Name != "" || user.Data["role"] != "admin" {
+		t.Errorf("unexpected user %v", user)
+	}
+}
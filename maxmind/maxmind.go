@@ -0,0 +1,39 @@
+// Package sentrymaxmind implements mdlwrsentry.GeoIPEnricher with a MaxMind GeoIP2 or GeoLite2 database.
+package sentrymaxmind
+
+import (
+	"net"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+type maxMindGeoIPEnricher struct {
+	db *maxminddb.Reader
+}
+
+// MaxMindGeoIPEnricher looks up the country ISO code and the first subdivision ISO code of an IP address.
+// The caller keeps ownership of db and closes it.
+func MaxMindGeoIPEnricher(db *maxminddb.Reader) mdlwrsentry.GeoIPEnricher {
+	return maxMindGeoIPEnricher{db: db}
+}
+
+func (e maxMindGeoIPEnricher) Enrich(ip net.IP) (countryCode, region string, err error) {
+	var record geoRecord
+	if err := e.db.Lookup(ip, &record); err != nil {
+		return "", "", err
+	}
+	if len(record.Subdivisions) > 0 {
+		region = record.Subdivisions[0].ISOCode
+	}
+	return record.Country.ISOCode, region, nil
+}
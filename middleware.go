@@ -0,0 +1,280 @@
+package sentry
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// CaptureOptions are the options of the 500 middlewares that do not depend on the framework.
+// They are embedded in Sentry500Options and in the options of the Gin and Goa middlewares.
+type CaptureOptions struct {
+	// HubModifiers are applied in order before the event is captured.
+	// The request is available with RequestFromContext.
+	HubModifiers      []HubModifier
+	NoLogResponseBody bool
+	// CaptureAsMessage are status codes sent to Sentry as warning messages instead of exceptions, e.g. 422
+	CaptureAsMessage []int
+	// MethodLevelMap sets the level of 500 errors per HTTP method, e.g. fatal for a failed DELETE.
+	// Status codes in CaptureAsMessage keep the warning level.
+	MethodLevelMap map[string]sentry.Level
+	// DefaultLevel is used for methods not in MethodLevelMap, empty keeps the error level
+	DefaultLevel sentry.Level
+	// DefaultSeverity is the Severity of the SentryError500 of the middleware, it takes precedence over MethodLevelMap.
+	// Empty keeps the level of the scope.
+	DefaultSeverity sentry.Level
+	// MaxBodyBytes truncates the logged response body, 0 means no limit
+	MaxBodyBytes int
+	// CaptureBodyAsAttachment attaches a response body longer than MaxBodyBytes to the event instead of logging it,
+	// see AttachResponseBody.
+	CaptureBodyAsAttachment bool
+	// MaxAttachmentBytes truncates the body attached with CaptureBodyAsAttachment, 0 means DefaultMaxAttachmentBytes
+	MaxAttachmentBytes int
+	// SkipBinaryBodyCapture does not log response bodies that are not UTF-8 text
+	SkipBinaryBodyCapture bool
+	// SkipContentTypes are content types whose body is not captured, see MatchContentType
+	SkipContentTypes []string
+	// BodyRedactPatterns are applied to the captured response body before it is truncated to MaxBodyBytes
+	BodyRedactPatterns []RedactPattern
+	// CaptureResponseContentType tags 500 errors with the media type of the response, see SetResponseContentTypeTag
+	CaptureResponseContentType bool
+	// ResponseBodyFields tag 500 errors with fields of a JSON response body, read after BodyRedactPatterns are applied
+	ResponseBodyFields []BodyFieldExtractor
+	// LazyBodyCapture only buffers the response body once the status code is known to be 500,
+	// so successful responses do not allocate
+	LazyBodyCapture bool
+	// ChunkedBodyMarker is appended to the body of a chunked response to show that it may be partial,
+	// the event also gets the response_encoding extra
+	ChunkedBodyMarker string
+	// CaptureRequestReplay sets the replay extra of 500 errors to the request without its sensitive headers
+	// and query parameters, see ReplayToCurl
+	CaptureRequestReplay bool
+	// CaptureRequestBody keeps the request body, up to DefaultReplayBodyBytes, for the replay
+	// and for the data of the event request, see RequestData
+	CaptureRequestBody bool
+	// SampleFunc returns the probability, between 0 and 1, to capture the event of a request with the status code,
+	// see TypeBasedSampler. All events are captured when nil.
+	SampleFunc func(*http.Request, int) float64
+	// PreFilterFunc is called before anything is done to capture the event of a request with the status code,
+	// returning false skips the capture: the hub is not cloned and the event is not built.
+	// err is the request error recorded with RecordRequestError, or else the error of the request context, it may be nil.
+	// Unlike SampleFunc it does not get the request, it is meant for cheap filtering on the error type.
+	PreFilterFunc func(err error, statusCode int) bool
+	// SlowRequestCapture sends a message for requests under 500 slower than the threshold
+	SlowRequestCapture *SlowRequestOpts
+	// ValidateOnCreate panics when the middleware is created with options that ValidateSentry500Options rejects
+	ValidateOnCreate bool
+	// CaptureTimeout bounds the time the response waits for the capture of a 500 error, 0 means no limit.
+	// The capture goes on in the background past the timeout.
+	CaptureTimeout time.Duration
+	// MeasureResponseSize sets the response_body_size data of the active span for every response
+	MeasureResponseSize bool
+	// CaptureClientIP sets the user IP address from the request, see ClientIP
+	CaptureClientIP bool
+	// AnonymizeIP truncates the captured IP address, see AnonymizeIP
+	AnonymizeIP bool
+	// GeoIPEnricher tags the event with the geo.country and geo.region of the client IP
+	GeoIPEnricher GeoIPEnricher
+	// CulpritExtractor sets the transaction of the event, shown by Sentry as the culprit instead of the middleware,
+	// see DefaultCulpritExtractor and RoutePatternCulpritExtractor. nil keeps the transaction.
+	CulpritExtractor CulpritExtractor
+	// ErrorCategorizer sets the error.category tag of 500 errors
+	ErrorCategorizer ErrorCategorizer
+	FingerprintOpts  FingerprintOpts
+}
+
+// DefaultCaptureOptions are the CaptureOptions of the default options of every 500 middleware.
+var DefaultCaptureOptions = CaptureOptions{
+	SkipBinaryBodyCapture: true,
+	LazyBodyCapture:       true,
+	ChunkedBodyMarker:     DefaultChunkedBodyMarker,
+	SkipContentTypes:      DefaultSkipContentTypes,
+	BodyRedactPatterns:    DefaultRedactPatterns,
+	DefaultLevel:          sentry.LevelError,
+	FingerprintOpts:       DefaultFingerprinter,
+}
+
+// Sentry500Options configures Middleware500.
+type Sentry500Options struct {
+	// Deprecated: use HubModifiers, ExtractContext is applied before them.
+	ExtractContext func(context.Context, *sentry.Scope)
+	CaptureOptions
+	// DecodeErrorBody decodes the body of a 500 response, it may tag the scope and returns the error name
+	// the event is grouped on instead of the body. An empty name keeps the body.
+	// It receives the body before MaxBodyBytes truncation, a body longer than 64 KiB is not decoded.
+	DecodeErrorBody func(scope *sentry.Scope, body []byte) (errorName string)
+	// FrameworkContext sets the framework context of the event, see FrameworkContextKey
+	FrameworkContext func(*sentry.Scope, *http.Request)
+	// PanicResponseBody is the body of the 500 response written by MiddlewareSentryRecover, empty by default
+	PanicResponseBody []byte
+	// PanicResponseContentType is the Content-Type of PanicResponseBody
+	PanicResponseContentType string
+	// PanicResponseHeaders are added to the 500 response written by MiddlewareSentryRecover
+	PanicResponseHeaders map[string]string
+	// IncludeEventIDInResponse sets the X-Sentry-Event-Id header of the panic response,
+	// and the sentry_event_id field of a JSON object PanicResponseBody
+	IncludeEventIDInResponse bool
+	// CaptureSource sets the capture.source tag of the events, empty does not tag them
+	CaptureSource CaptureSource
+}
+
+// maxDecodeBodyBytes is the most of the response body captured for DecodeErrorBody when MaxBodyBytes is lower.
+const maxDecodeBodyBytes = 64 * 1024
+
+var DefaultSentry500Opts = Sentry500Options{
+	CaptureOptions: DefaultCaptureOptions,
+	CaptureSource:  CaptureSourceHTTPMiddleware500,
+}
+
+// Middleware500 is a net/http middleware that captures the response status code and sends to Sentry if code=500.
+// It works with any router, the Goa middleware is built on it.
+func Middleware500(opts Sentry500Options) func(http.Handler) http.Handler {
+	if opts.ValidateOnCreate {
+		if errs := ValidateSentry500Options(opts); len(errs) > 0 {
+			panic(FormatOptionErrors("Middleware500", errs))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(WithRoutePatternRecorder(WithRequestErrorRecorder(WithSuppressibleCapture(r.Context()))))
+			requestBody := func() []byte { return nil }
+			if opts.CaptureRequestBody {
+				requestBody = RecordRequestBody(r, DefaultReplayBodyBytes)
+			}
+
+			maxBytes := RedactionCaptureLimit(
+				BodyCaptureLimit(opts.MaxBodyBytes, opts.CaptureBodyAsAttachment, opts.MaxAttachmentBytes),
+				opts.BodyRedactPatterns,
+			)
+			if opts.DecodeErrorBody != nil && maxBytes > 0 {
+				// the error body is decoded before it is truncated
+				maxBytes = max(maxBytes, maxDecodeBodyBytes)
+			}
+			// Create a custom response writer to capture the status code
+			captureWriter := &statusCaptureResponseWriter{
+				ResponseWriter:   w,
+				maxBytes:         maxBytes,
+				skipContentTypes: opts.SkipContentTypes,
+				lazy:             opts.LazyBodyCapture,
+			}
+
+			start := time.Now()
+			// Call the next middleware/handler in the chain
+			next.ServeHTTP(captureWriter.withOptionalInterfaces(), r)
+
+			if opts.MeasureResponseSize {
+				if span := sentry.SpanFromContext(r.Context()); span != nil {
+					span.SetData("response_body_size", captureWriter.bytesWritten)
+				}
+			}
+
+			// Retrieve the captured response status code
+			respStatus := captureWriter.statusCode
+			if slow := opts.SlowRequestCapture; slow != nil && respStatus < 500 {
+				if elapsed := time.Since(start); elapsed >= slow.Threshold {
+					hub := sentry.GetHubFromContext(r.Context())
+					if hub == nil {
+						hub = sentry.CurrentHub().Clone()
+					}
+					CaptureSlowRequest(hub, r, elapsed, *slow)
+				}
+			}
+			if (respStatus == 500 || slices.Contains(opts.CaptureAsMessage, respStatus)) &&
+				!IsSentrySuppressed(r.Context()) &&
+				(opts.PreFilterFunc == nil || opts.PreFilterFunc(RequestError(r.Context()), respStatus)) &&
+				(opts.SampleFunc == nil || rand.Float64() < opts.SampleFunc(r, respStatus)) {
+				ctx := r.Context()
+				hubOrig := sentry.GetHubFromContext(ctx)
+				if hubOrig == nil {
+					hubOrig = sentry.CurrentHub().Clone()
+				}
+				hub := HubCustomFingerprint(hubOrig, opts.FingerprintOpts)
+				// The scope is only modified in WithScope, the event is captured before it is popped
+				hub.WithScope(func(scope *sentry.Scope) {
+					setUpRequestScope(hub, r, opts, opts.CaptureSource, requestBody)
+					urlStr := ""
+					if url := r.URL; url != nil {
+						urlStr = url.String()
+					}
+
+					if respStatus != 500 {
+						CaptureStatusMessage(hub, respStatus, urlStr)
+						return
+					}
+
+					err500 := SentryError500{
+						Url:      urlStr,
+						Body:     "",
+						Severity: opts.DefaultSeverity,
+					}
+					body := RedactSensitiveData(captureWriter.body, opts.BodyRedactPatterns)
+					if !opts.NoLogResponseBody {
+						contentType := w.Header().Get("Content-Type")
+						err500.Body = ResponseBodyForSentry(body, contentType, opts.MaxBodyBytes, opts.SkipBinaryBodyCapture)
+						if opts.CaptureBodyAsAttachment && err500.Body != "" &&
+							AttachResponseBody(scope, body, contentType, opts.MaxBodyBytes, opts.MaxAttachmentBytes) {
+							err500.Body = ""
+						}
+					}
+					if opts.DecodeErrorBody != nil && !captureWriter.truncated() {
+						err500.ErrorName = opts.DecodeErrorBody(scope, captureWriter.body)
+					}
+					ExtractBodyFields(scope, body, opts.ResponseBodyFields)
+					if opts.CaptureResponseContentType {
+						SetResponseContentTypeTag(scope, w.Header().Get("Content-Type"))
+					}
+					if IsChunkedResponse(w.Header(), captureWriter.flushed) {
+						scope.SetExtra("response_encoding", "chunked")
+						if err500.Body != "" {
+							err500.Body += opts.ChunkedBodyMarker
+						}
+					}
+					if opts.CaptureRequestReplay {
+						scope.SetExtra(ReplayExtraKey, RequestReplay(r, requestBody()))
+					}
+					if level := MethodLevel(r.Method, opts.MethodLevelMap, opts.DefaultLevel); level != "" {
+						scope.SetLevel(level)
+					}
+					SetErrorCategory(hub, opts.ErrorCategorizer, err500)
+					CaptureExceptionWithTimeout(hub, err500, opts.CaptureTimeout)
+				})
+			}
+		})
+	}
+}
+
+// setUpRequestScope sets the request, the capture source and the HubModifiers of the options on the scope of hub,
+// for the events of Middleware500 and MiddlewareSentryRecover.
+func setUpRequestScope(hub *sentry.Hub, r *http.Request, opts Sentry500Options, source CaptureSource, requestBody func() []byte) {
+	ctx := r.Context()
+	scope := hub.Scope()
+	SetCaptureSource(hub, source)
+	scope.SetRequest(r)
+	if opts.FrameworkContext != nil {
+		opts.FrameworkContext(scope, r)
+	}
+
+	modifiers := []HubModifier{ContextTagsModifier}
+	if opts.ExtractContext != nil {
+		modifiers = append(modifiers, LegacyExtractContextModifier(opts.ExtractContext))
+	}
+	if opts.CaptureClientIP {
+		modifiers = append(modifiers, ClientIPModifier(opts.AnonymizeIP))
+	}
+	if opts.GeoIPEnricher != nil {
+		modifiers = append(modifiers, GeoIPModifier(opts.GeoIPEnricher))
+	}
+	if opts.CaptureRequestBody {
+		modifiers = append(modifiers, RequestDataModifier(requestBody))
+	}
+	if opts.CulpritExtractor != nil {
+		modifiers = append(modifiers, CulpritModifier(opts.CulpritExtractor))
+	}
+	modifiers = append(modifiers, opts.HubModifiers...)
+	ApplyHubModifiers(ContextWithRequest(ctx, r), hub, modifiers)
+	AddSlowRequestBreadcrumb(ctx, hub)
+}
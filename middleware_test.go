@@ -0,0 +1,307 @@
+package sentry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func newMiddleware500Server(t *testing.T, opts Sentry500Options) (*httptest.Server, *sentrytest.EventRecorder) {
+	t.Helper()
+	hub, transport := sentrytest.NewRecordingHub(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"name":"db_down","message":"database unavailable"}`)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "row 1\n")
+		w.(http.Flusher).Flush()
+	})
+	mux.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	handler := Middleware500(opts)(mux)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(sentry.SetHubOnContext(r.Context(), hub)))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, transport
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func TestMiddleware500(t *testing.T) {
+	ts, transport := newMiddleware500Server(t, DefaultSentry500Opts)
+	if status := getStatus(t, ts.URL+"/ok"); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if status := getStatus(t, ts.URL+"/fail"); status != http.StatusInternalServerError {
+		t.Fatalf("unexpected status %d", status)
+	}
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected only the 500 to be captured, got %d events", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if event.Level != sentry.LevelError || event.Request == nil || event.Request.Method != http.MethodGet {
+		t.Errorf("unexpected event %s %+v", event.Level, event.Request)
+	}
+	if source := event.Tags[CaptureSourceTag]; source != string(CaptureSourceHTTPMiddleware500) {
+		t.Errorf("unexpected capture source %q", source)
+	}
+	if exception := event.Exception[len(event.Exception)-1]; exception.Value == "" {
+		t.Errorf("expected the response body in the exception %+v", exception)
+	}
+}
+
+func TestMiddleware500Hooks(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.CaptureAsMessage = []int{http.StatusUnprocessableEntity}
+	opts.DecodeErrorBody = func(scope *sentry.Scope, body []byte) string {
+		scope.SetTag("decoded", "true")
+		return "db_down"
+	}
+	opts.FrameworkContext = func(scope *sentry.Scope, r *http.Request) {
+		scope.SetContext(FrameworkContextKey, sentry.Context{"name": "mux"})
+	}
+	ts, transport := newMiddleware500Server(t, opts)
+	getStatus(t, ts.URL+"/fail")
+	getStatus(t, ts.URL+"/invalid")
+
+	if len(transport.Events()) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if event.Tags["decoded"] != "true" || event.Contexts[FrameworkContextKey]["name"] != "mux" {
+		t.Errorf("expected the hooks to be applied %v %v", event.Tags, event.Contexts)
+	}
+	if message := transport.Events()[1]; message.Level != sentry.LevelWarning || len(message.Exception) != 0 {
+		t.Errorf("expected a warning message for the 422 %s %v", message.Level, message.Exception)
+	}
+}
+
+func TestMiddleware500ResponseBodyFields(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.ResponseBodyFields = []BodyFieldExtractor{
+		{JSONPath: "name", SentryTag: "error_name"},
+		{JSONPath: "code", SentryTag: "error_code"},
+	}
+	ts, transport := newMiddleware500Server(t, opts)
+	getStatus(t, ts.URL+"/fail")
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	tags := transport.Events()[0].Tags
+	if tags["error_name"] != "db_down" {
+		t.Errorf("expected the error_name tag %v", tags)
+	}
+	if _, ok := tags["error_code"]; ok {
+		t.Errorf("expected the absent path to be skipped %v", tags)
+	}
+}
+
+func TestMiddleware500RedactBeforeTruncating(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.BodyRedactPatterns = []RedactPattern{CreditCardPattern}
+	// the limit falls inside the card number
+	opts.MaxBodyBytes = len("payment failed for card 4111 11")
+	handler := Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "payment failed for card 4111 1111 1111 1111, declined")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	e500 := transport.Events()[0].Extra["sentry_error"].(SentryError500)
+	if e500.Body != "payment failed for card REDACTE" {
+		t.Errorf("expected the card to be redacted before the body is truncated, got %q", e500.Body)
+	}
+}
+
+func TestMiddleware500ResponseBodyFieldsRedacted(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.BodyRedactPatterns = []RedactPattern{{Regex: regexp.MustCompile(`db_down`), Replacement: []byte("<redacted>")}}
+	opts.ResponseBodyFields = []BodyFieldExtractor{{JSONPath: "name", SentryTag: "error_name"}}
+	ts, transport := newMiddleware500Server(t, opts)
+	getStatus(t, ts.URL+"/fail")
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	if tag := transport.Events()[0].Tags["error_name"]; tag != "<redacted>" {
+		t.Errorf("expected the field to be extracted from the redacted body, got %q", tag)
+	}
+}
+
+func TestMiddleware500CaptureResponseContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/json; charset=utf-8", "application/json"},
+		{"text/html; charset=UTF-8", "text/html"},
+		{"text/plain", "text/plain"},
+		{"", ""},
+	}
+	opts := DefaultSentry500Opts
+	opts.CaptureResponseContentType = true
+	for _, test := range tests {
+		hub, transport := sentrytest.NewRecordingHub(t)
+		handler := Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.contentType != "" {
+				w.Header().Set("Content-Type", test.contentType)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(transport.Events()) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+		}
+		if got, ok := transport.Events()[0].Tags[ResponseContentTypeTag]; got != test.want || ok != (test.want != "") {
+			t.Errorf("%q: expected the tag %q, got %q", test.contentType, test.want, got)
+		}
+	}
+}
+
+func TestMiddleware500FlushedWithoutContentLength(t *testing.T) {
+	ts, transport := newMiddleware500Server(t, DefaultSentry500Opts)
+	getStatus(t, ts.URL+"/stream")
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if event.Extra["response_encoding"] != "chunked" || event.Exception[len(event.Exception)-1].Value != "500 /stream:row 1\n"+DefaultChunkedBodyMarker {
+		t.Errorf("expected a streamed response %v %q", event.Extra, event.Exception[len(event.Exception)-1].Value)
+	}
+}
+
+func TestMiddleware500CaptureBodyAsAttachment(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.MaxBodyBytes = 10
+	opts.CaptureBodyAsAttachment = true
+	ts, transport := newMiddleware500Server(t, opts)
+	getStatus(t, ts.URL+"/fail")
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if len(event.Attachments) != 1 {
+		t.Fatalf("expected the body attachment %v", event.Attachments)
+	}
+	attachment := event.Attachments[0]
+	if attachment.Filename != ResponseBodyAttachmentName || string(attachment.Payload) != `{"name":"db_down","message":"database unavailable"}` {
+		t.Errorf("unexpected attachment %s %s", attachment.Filename, attachment.Payload)
+	}
+	if body, ok := event.Extra["response_body"]; ok {
+		t.Errorf("expected the body only in the attachment %q", body)
+	}
+	if e500 := event.Extra["sentry_error"].(SentryError500); e500.Body != "" {
+		t.Errorf("expected the body only in the attachment %q", e500.Body)
+	}
+	if value := event.Exception[len(event.Exception)-1].Value; strings.Contains(value, "db_down") {
+		t.Errorf("expected the body only in the attachment %q", value)
+	}
+}
+
+func TestMiddleware500MaxAttachmentBytes(t *testing.T) {
+	opts := DefaultSentry500Opts
+	opts.MaxBodyBytes = 10
+	opts.CaptureBodyAsAttachment = true
+	opts.MaxAttachmentBytes = 17
+	ts, transport := newMiddleware500Server(t, opts)
+	getStatus(t, ts.URL+"/fail")
+
+	if len(transport.Events()) != 1 || len(transport.Events()[0].Attachments) != 1 {
+		t.Fatalf("expected 1 event with an attachment")
+	}
+	if payload := string(transport.Events()[0].Attachments[0].Payload); payload != `{"name":"db_down"` {
+		t.Errorf("expected the attachment to be truncated %q", payload)
+	}
+}
+
+type notFoundErr struct{}
+
+func (notFoundErr) Error() string { return "not found" }
+
+func TestMiddleware500PreFilterFunc(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	var gotErr error
+	var gotStatus int
+	opts := DefaultSentry500Opts
+	opts.PreFilterFunc = func(err error, statusCode int) bool {
+		gotErr, gotStatus = err, statusCode
+		return !errors.As(err, &notFoundErr{})
+	}
+	for _, handlerErr := range []error{notFoundErr{}, errors.New("db down")} {
+		handler := Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			RecordRequestError(r.Context(), handlerErr)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if !errors.Is(gotErr, handlerErr) || gotStatus != http.StatusInternalServerError {
+			t.Errorf("unexpected PreFilterFunc arguments %v %d", gotErr, gotStatus)
+		}
+	}
+	if len(transport.Events()) != 1 {
+		t.Errorf("expected only the error that is not filtered to be captured, got %d events", len(transport.Events()))
+	}
+}
+
+func BenchmarkMiddleware500PreFilterFunc(b *testing.B) {
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@o1.ingest.sentry.io/1", Transport: &sentrytest.EventRecorder{}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		RecordRequestError(r.Context(), notFoundErr{})
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	filtered := DefaultSentry500Opts
+	filtered.PreFilterFunc = func(err error, _ int) bool { return !errors.As(err, &notFoundErr{}) }
+	for name, opts := range map[string]Sentry500Options{"NoFilter": DefaultSentry500Opts, "PreFilterFunc": filtered} {
+		b.Run(name, func(b *testing.B) {
+			hub := sentry.NewHub(client, sentry.NewScope())
+			mw := Middleware500(opts)(http.HandlerFunc(handler))
+			req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+			req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mw.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
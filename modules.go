@@ -0,0 +1,77 @@
+package sentry
+
+import (
+	"runtime/debug"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type PopulateModulesOptions struct {
+	// FilterLocalModules excludes modules built from a local directory,
+	// as happens with go.work or replace directives, so that local paths are not sent to Sentry.
+	FilterLocalModules bool
+	// ReadBuildInfo defaults to debug.ReadBuildInfo
+	ReadBuildInfo func() (*debug.BuildInfo, bool)
+}
+
+// PopulateModules returns a BeforeSend function that sets event.Modules from the build info.
+// The build info is read once when PopulateModules is called.
+func PopulateModules(opts PopulateModulesOptions) BeforeSendFn {
+	readBuildInfo := opts.ReadBuildInfo
+	if readBuildInfo == nil {
+		readBuildInfo = debug.ReadBuildInfo
+	}
+	var modules map[string]string
+	if info, ok := readBuildInfo(); ok {
+		modules = ModulesFromBuildInfo(info, opts.FilterLocalModules)
+	}
+	return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		if modules != nil {
+			event.Modules = modules
+		}
+		return event
+	}
+}
+
+// ModulesFromBuildInfo maps module paths to versions for the main module and its dependencies.
+// Replaced modules report the version of the replacement.
+func ModulesFromBuildInfo(info *debug.BuildInfo, filterLocal bool) map[string]string {
+	modules := make(map[string]string, len(info.Deps)+1)
+	add := func(mod *debug.Module) {
+		if mod == nil || mod.Path == "" {
+			return
+		}
+		version := mod.Version
+		if mod.Replace != nil {
+			if filterLocal && isLocalModule(mod.Replace) {
+				return
+			}
+			version = mod.Replace.Version
+		}
+		if filterLocal {
+			if isLocalModule(mod) {
+				return
+			}
+			version, _, _ = strings.Cut(version, "+h1:")
+		}
+		modules[mod.Path] = version
+	}
+	add(&info.Main)
+	for _, dep := range info.Deps {
+		add(dep)
+	}
+	return modules
+}
+
+func isLocalModule(mod *debug.Module) bool {
+	if mod.Version == "(devel)" {
+		return true
+	}
+	for _, prefix := range []string{"/", "./", "../"} {
+		if strings.HasPrefix(mod.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestPopulateModulesFilterLocal(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/getsentry/sentry-go", Version: "v0.31.1"},
+			{Path: "example.com/legacy", Version: "v1.2.3+incompatible+h1:abc="},
+			{Path: "example.com/shared", Version: "v0.1.0", Replace: &debug.Module{Path: "/home/user/shared"}},
+			{Path: "example.com/relative", Version: "v0.1.0", Replace: &debug.Module{Path: "../relative"}},
+			{Path: "example.com/forked", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+		},
+	}
+	readBuildInfo := func() (*debug.BuildInfo, bool) { return info, true }
+
+	beforeSend := PopulateModules(PopulateModulesOptions{FilterLocalModules: true, ReadBuildInfo: readBuildInfo})
+	modules := beforeSend(&sentry.Event{}, nil).Modules
+	want := map[string]string{
+		"github.com/getsentry/sentry-go": "v0.31.1",
+		"example.com/legacy":             "v1.2.3+incompatible",
+		"example.com/forked":             "v1.0.1",
+	}
+	if len(modules) != len(want) {
+		t.Fatalf("unexpected %v", modules)
+	}
+	for path, version := range want {
+		if modules[path] != version {
+			t.Errorf("%s: unexpected version %q", path, modules[path])
+		}
+	}
+
+	beforeSend = PopulateModules(PopulateModulesOptions{ReadBuildInfo: readBuildInfo})
+	if modules := beforeSend(&sentry.Event{}, nil).Modules; len(modules) != 6 {
+		t.Errorf("expected local modules without filtering %v", modules)
+	}
+}
@@ -0,0 +1,89 @@
+package sentry
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// HubFactory returns the hub that should be used for the given request context.
+type HubFactory func(ctx context.Context) *sentry.Hub
+
+// EvictedClientFlushTimeout is how long an evicted client has to deliver its buffered events.
+var EvictedClientFlushTimeout = 2 * time.Second
+
+// NewMultiTenantHubFactory creates a HubFactory that sends events to the DSN chosen by dsnSelector.
+// One client is created per DSN and reused. At most capacity clients are kept:
+// the least recently used client is evicted and flushed when a new DSN is seen.
+func NewMultiTenantHubFactory(capacity int, baseOptions sentry.ClientOptions, dsnSelector func(context.Context) string) HubFactory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	cache := &clientLRU{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+	}
+	return func(ctx context.Context) *sentry.Hub {
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		hub = hub.Clone()
+
+		dsn := dsnSelector(ctx)
+		client, err := cache.get(dsn, func() (*sentry.Client, error) {
+			options := baseOptions
+			options.Dsn = dsn
+			return sentry.NewClient(options)
+		})
+		if err != nil {
+			// keep sending to the client that was already bound
+			return hub
+		}
+		hub.BindClient(client)
+		return hub
+	}
+}
+
+type clientLRUEntry struct {
+	dsn    string
+	client *sentry.Client
+}
+
+// clientLRU is a least recently used cache of sentry clients keyed by DSN.
+type clientLRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is the most recently used
+	entries  map[string]*list.Element
+}
+
+func (lru *clientLRU) get(dsn string, create func() (*sentry.Client, error)) (*sentry.Client, error) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.entries[dsn]; ok {
+		lru.order.MoveToFront(elem)
+		return elem.Value.(*clientLRUEntry).client, nil
+	}
+
+	client, err := create()
+	if err != nil {
+		return nil, err
+	}
+	lru.entries[dsn] = lru.order.PushFront(&clientLRUEntry{dsn: dsn, client: client})
+
+	for lru.order.Len() > lru.capacity {
+		oldest := lru.order.Back()
+		lru.order.Remove(oldest)
+		evicted := oldest.Value.(*clientLRUEntry)
+		delete(lru.entries, evicted.dsn)
+		// Flushing blocks, don't hold up the request or the lock
+		go evicted.client.Flush(EvictedClientFlushTimeout)
+	}
+	return client, nil
+}
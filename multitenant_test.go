@@ -0,0 +1,56 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type flushRecordingTransport struct {
+	flushed chan struct{}
+}
+
+func (t *flushRecordingTransport) Flush(_ time.Duration) bool {
+	t.flushed <- struct{}{}
+	return true
+}
+func (t *flushRecordingTransport) Configure(_ sentry.ClientOptions) {}
+func (t *flushRecordingTransport) SendEvent(_ *sentry.Event)        {}
+func (t *flushRecordingTransport) Close()                           {}
+
+type dsnKey struct{}
+
+func TestMultiTenantHubFactoryEvicts(t *testing.T) {
+	transport := &flushRecordingTransport{flushed: make(chan struct{}, 1)}
+	factory := NewMultiTenantHubFactory(1, sentry.ClientOptions{Transport: transport}, func(ctx context.Context) string {
+		return ctx.Value(dsnKey{}).(string)
+	})
+
+	ctx1 := context.WithValue(context.Background(), dsnKey{}, "https://key@o1.ingest.sentry.io/1")
+	ctx2 := context.WithValue(context.Background(), dsnKey{}, "https://key@o1.ingest.sentry.io/2")
+
+	first := factory(ctx1).Client()
+	if again := factory(ctx1).Client(); again != first {
+		t.Errorf("expected the client to be reused")
+	}
+	select {
+	case <-transport.flushed:
+		t.Fatal("no client should be evicted yet")
+	default:
+	}
+
+	if second := factory(ctx2).Client(); second == first {
+		t.Errorf("expected a new client for the second DSN")
+	}
+	select {
+	case <-transport.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("evicted client was not flushed")
+	}
+
+	if third := factory(ctx1).Client(); third == first {
+		t.Errorf("expected the evicted client to be recreated")
+	}
+}
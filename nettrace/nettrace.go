@@ -0,0 +1,131 @@
+// Package sentrynettrace sends the events of a golang.org/x/net/trace Trace as breadcrumbs of the Sentry events.
+// It is a separate package because importing golang.org/x/net/trace registers /debug/requests and /debug/events
+// on http.DefaultServeMux.
+package sentrynettrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/getsentry/sentry-go"
+	"golang.org/x/net/trace"
+)
+
+// Family is the net/trace family of the traces started by Middleware and Start.
+const Family = "sentry_capture"
+
+// Middleware puts a TraceCapture in the request context for the handler to log to with trace.FromContext.
+// Put it before the 500 middleware and add Modifier to the HubModifiers of the 500 middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, tr := Start(r)
+		defer tr.Finish()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start returns the request with a new TraceCapture in its context, finish the trace once the request is served.
+// It is Middleware for routers that do not use net/http middlewares, e.g. in a gin middleware:
+//
+//	var tr *sentrynettrace.TraceCapture
+//	c.Request, tr = sentrynettrace.Start(c.Request)
+//	defer tr.Finish()
+//	c.Next()
+func Start(r *http.Request) (*http.Request, *TraceCapture) {
+	tr := NewTraceCapture(trace.New(Family, r.URL.Path))
+	return r.WithContext(trace.NewContext(r.Context(), tr)), tr
+}
+
+// Modifier marks the TraceCapture of the request as failed and adds its events as breadcrumbs of the event.
+// Requests without a TraceCapture are left unchanged.
+var Modifier mdlwrsentry.HubModifier = mdlwrsentry.HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+	tr, ok := trace.FromContext(ctx)
+	if !ok {
+		return
+	}
+	if tc, ok := tr.(*TraceCapture); ok {
+		tc.SetError()
+		tc.AddBreadcrumbs(hub)
+	}
+})
+
+// DefaultTraceCaptureMaxEvents is the number of net/trace events kept for breadcrumbs.
+const DefaultTraceCaptureMaxEvents = 100
+
+type traceCaptureEvent struct {
+	when   time.Time
+	lazy   fmt.Stringer
+	format string
+	args   []interface{}
+}
+
+// TraceCapture is a net/trace.Trace that also keeps the logged events so that they can be sent
+// as Sentry breadcrumbs: a net/trace.Trace cannot be read back.
+// Events logged as sensitive are not kept.
+type TraceCapture struct {
+	trace.Trace
+
+	mu        sync.Mutex
+	events    []traceCaptureEvent
+	maxEvents int
+}
+
+// NewTraceCapture wraps tr, put it in the request context with trace.NewContext.
+func NewTraceCapture(tr trace.Trace) *TraceCapture {
+	return &TraceCapture{Trace: tr, maxEvents: DefaultTraceCaptureMaxEvents}
+}
+
+func (tc *TraceCapture) LazyLog(x fmt.Stringer, sensitive bool) {
+	tc.Trace.LazyLog(x, sensitive)
+	if !sensitive {
+		tc.add(traceCaptureEvent{when: time.Now(), lazy: x})
+	}
+}
+
+func (tc *TraceCapture) LazyPrintf(format string, a ...interface{}) {
+	tc.Trace.LazyPrintf(format, a...)
+	tc.add(traceCaptureEvent{when: time.Now(), format: format, args: a})
+}
+
+func (tc *TraceCapture) SetMaxEvents(m int) {
+	tc.Trace.SetMaxEvents(m)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if len(tc.events) == 0 && m > 0 {
+		tc.maxEvents = m
+	}
+}
+
+func (tc *TraceCapture) add(event traceCaptureEvent) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if len(tc.events) == tc.maxEvents {
+		// like net/trace keep the most recent events
+		tc.events = tc.events[1:]
+	}
+	tc.events = append(tc.events, event)
+}
+
+// AddBreadcrumbs adds the logged events to the scope of the hub, call it before Finish.
+func (tc *TraceCapture) AddBreadcrumbs(hub *sentry.Hub) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, event := range tc.events {
+		var message string
+		if event.lazy != nil {
+			message = event.lazy.String()
+		} else {
+			message = fmt.Sprintf(event.format, event.args...)
+		}
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category:  "net/trace",
+			Message:   message,
+			Level:     sentry.LevelInfo,
+			Timestamp: event.when,
+		}, nil)
+	}
+}
@@ -0,0 +1,59 @@
+package sentrynettrace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+	"golang.org/x/net/trace"
+)
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestMiddlewareModifier(t *testing.T) {
+	hub, recorder := sentrytest.NewRecordingHub(t)
+	opts := mdlwrsentry.DefaultSentry500Opts
+	opts.HubModifiers = []mdlwrsentry.HubModifier{Modifier}
+	handler := Middleware(mdlwrsentry.Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr, ok := trace.FromContext(r.Context())
+		if !ok {
+			t.Fatal("no trace in the context")
+		}
+		tr.LazyPrintf("loading order %d", 7)
+		tr.LazyLog(stringer("card 4111"), true)
+		w.WriteHeader(http.StatusInternalServerError)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	breadcrumbs := events[0].Breadcrumbs
+	if len(breadcrumbs) != 1 || breadcrumbs[0].Message != "loading order 7" || breadcrumbs[0].Category != "net/trace" {
+		t.Errorf("unexpected breadcrumbs %v", breadcrumbs)
+	}
+}
+
+func TestModifierWithoutTrace(t *testing.T) {
+	hub, recorder := sentrytest.NewRecordingHub(t)
+	opts := mdlwrsentry.DefaultSentry500Opts
+	opts.HubModifiers = []mdlwrsentry.HubModifier{Modifier}
+	handler := mdlwrsentry.Middleware500(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if events := recorder.Events(); len(events) != 1 || len(events[0].Breadcrumbs) != 0 {
+		t.Errorf("expected the event without breadcrumbs %v", events)
+	}
+}
@@ -0,0 +1,80 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// MaxPayloadBytes is the largest raw event ProxyEvent accepts.
+var MaxPayloadBytes = 200 * 1024
+
+var ErrPayloadTooLarge = errors.New("sentry event payload too large")
+var ErrEventDropped = errors.New("sentry event dropped")
+
+// ProxyEvent re-reports an event that was sent to the backend by a browser.
+// The browser DSN is redacted: the event is sent with the DSN of the hub's client.
+// Exception types and stack frames are kept as is so that source maps can still be applied.
+// Fields describing the browser SDK and the page session are dropped.
+func ProxyEvent(hub *sentry.Hub, rawEvent []byte) (sentry.EventID, error) {
+	if len(rawEvent) > MaxPayloadBytes {
+		return "", fmt.Errorf("%w: %d bytes, max %d", ErrPayloadTooLarge, len(rawEvent), MaxPayloadBytes)
+	}
+	proxied := proxiedEvent{}
+	if err := json.Unmarshal(RedactDSN(rawEvent), &proxied); err != nil {
+		return "", fmt.Errorf("decoding proxied sentry event: %w", err)
+	}
+	event := proxied.Event
+	exceptions, err := decodeExceptions(proxied.Exception)
+	if err != nil {
+		return "", fmt.Errorf("decoding proxied sentry event exceptions: %w", err)
+	}
+	event.Exception = exceptions
+	stripBrowserFields(&event)
+
+	eventID := hub.CaptureEvent(&event)
+	if eventID == nil {
+		return "", ErrEventDropped
+	}
+	return *eventID, nil
+}
+
+// proxiedEvent decodes the exception field separately:
+// the JavaScript SDK nests exceptions under "values" while the Go SDK sends a list.
+type proxiedEvent struct {
+	sentry.Event
+	Exception json.RawMessage `json:"exception,omitempty"`
+}
+
+func decodeExceptions(raw json.RawMessage) ([]sentry.Exception, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var exceptions []sentry.Exception
+	if err := json.Unmarshal(raw, &exceptions); err == nil {
+		return exceptions, nil
+	}
+	var nested struct {
+		Values []sentry.Exception `json:"values"`
+	}
+	if err := json.Unmarshal(raw, &nested); err != nil {
+		return nil, err
+	}
+	return nested.Values, nil
+}
+
+// stripBrowserFields removes the fields that the Go client sets itself or that only make sense in the browser.
+func stripBrowserFields(event *sentry.Event) {
+	// A new ID is assigned so that a retried proxy request is not deduplicated against the browser's own send.
+	event.EventID = ""
+	event.Sdk = sentry.SdkInfo{}
+	event.ServerName = ""
+	event.Modules = nil
+	// UI breadcrumbs (clicks, console, navigation) are noise in the backend project.
+	event.Breadcrumbs = nil
+	if event.Request != nil {
+		event.Request.Cookies = ""
+	}
+}
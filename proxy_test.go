@@ -0,0 +1,66 @@
+package sentry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+)
+
+const browserEvent = `{
+	"event_id": "0d1b5cbbd8b54e1db0ea39b8b0c0a1e2",
+	"platform": "javascript",
+	"level": "error",
+	"sdk": {"name": "sentry.javascript.browser", "version": "8.0.0"},
+	"breadcrumbs": [{"category": "ui.click", "message": "button#submit"}],
+	"request": {"url": "https://app.example.com/checkout", "cookies": "session=abc",
+		"headers": {"User-Agent": "Mozilla/5.0"}},
+	"extra": {"dsn": "https://abc@o2.ingest.sentry.io/456"},
+	"exception": {"values": [{
+		"type": "TypeError",
+		"value": "Cannot read properties of undefined (reading 'id')",
+		"stacktrace": {"frames": [
+			{"filename": "https://app.example.com/static/main.js", "function": "submit", "lineno": 1, "colno": 4521}
+		]}
+	}]}
+}`
+
+func TestProxyEvent(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	eventID, err := ProxyEvent(hub, []byte(browserEvent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventID == "" || eventID == "0d1b5cbbd8b54e1db0ea39b8b0c0a1e2" {
+		t.Errorf("expected a new event id, got %s", eventID)
+	}
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if len(event.Exception) != 1 || event.Exception[0].Type != "TypeError" {
+		t.Fatalf("unexpected exception %v", event.Exception)
+	}
+	frames := event.Exception[0].Stacktrace.Frames
+	if len(frames) != 1 || frames[0].Function != "submit" || frames[0].Colno != 4521 {
+		t.Errorf("unexpected frames %v", frames)
+	}
+	if len(event.Breadcrumbs) != 0 {
+		t.Errorf("expected breadcrumbs to be stripped %v", event.Breadcrumbs)
+	}
+	if event.Request == nil || event.Request.Cookies != "" {
+		t.Errorf("expected cookies to be stripped %v", event.Request)
+	}
+	if dsn := event.Extra["dsn"]; dsn != "REDACTED" {
+		t.Errorf("expected dsn to be redacted %v", dsn)
+	}
+}
+
+func TestProxyEventTooLarge(t *testing.T) {
+	hub, _ := sentrytest.NewRecordingHub(t)
+	raw := `{"message":"` + strings.Repeat("a", MaxPayloadBytes) + `"}`
+	if _, err := ProxyEvent(hub, []byte(raw)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("unexpected %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package sentry
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrSentryQuotaExceeded is returned by the transport of QuotaGuard.Wrap for the requests past the quota.
+// The event is dropped: do not retry it, the quota is only available again once older requests leave the window.
+var ErrSentryQuotaExceeded = errors.New("sentry event quota exceeded")
+
+// QuotaGuard caps the number of events sent to Sentry in a sliding window,
+// so that a misconfigured deployment does not use up the quota of the Sentry organization.
+type QuotaGuard struct {
+	maxEvents int
+	period    time.Duration
+	now       func() time.Time
+
+	mu sync.Mutex
+	// sent are the times of the requests of the window, oldest first
+	sent   []time.Time
+	logged bool
+}
+
+// NewQuotaGuard allows maxEventsPerPeriod requests to Sentry in any period.
+func NewQuotaGuard(maxEventsPerPeriod int, period time.Duration) *QuotaGuard {
+	return &QuotaGuard{
+		maxEvents: maxEventsPerPeriod,
+		period:    period,
+		now:       time.Now,
+	}
+}
+
+// Wrap returns a transport that drops the requests past the quota with ErrSentryQuotaExceeded.
+// The first dropped request is logged with slog.Warn, the next ones are not until the quota is available again.
+func (qg *QuotaGuard) Wrap(inner http.RoundTripper) http.RoundTripper {
+	return quotaTransport{guard: qg, inner: inner}
+}
+
+type quotaTransport struct {
+	guard *QuotaGuard
+	inner http.RoundTripper
+}
+
+func (qt quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !qt.guard.take() {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, ErrSentryQuotaExceeded
+	}
+	return qt.inner.RoundTrip(req)
+}
+
+// Remaining returns the number of requests still allowed in the current window.
+func (qg *QuotaGuard) Remaining() int {
+	qg.mu.Lock()
+	defer qg.mu.Unlock()
+	qg.expire(qg.now())
+	return qg.maxEvents - len(qg.sent)
+}
+
+func (qg *QuotaGuard) take() bool {
+	qg.mu.Lock()
+	defer qg.mu.Unlock()
+	now := qg.now()
+	qg.expire(now)
+	if len(qg.sent) >= qg.maxEvents {
+		if !qg.logged {
+			qg.logged = true
+			slog.Warn("Sentry event quota exceeded, dropping events", "max_events", qg.maxEvents, "period", qg.period.String())
+		}
+		return false
+	}
+	qg.logged = false
+	qg.sent = append(qg.sent, now)
+	return true
+}
+
+// expire removes the requests older than the window.
+func (qg *QuotaGuard) expire(now time.Time) {
+	start := now.Add(-qg.period)
+	i := 0
+	for i < len(qg.sent) && !qg.sent[i].After(start) {
+		i++
+	}
+	qg.sent = qg.sent[i:]
+}
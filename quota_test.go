@@ -0,0 +1,64 @@
+package sentry
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaGuard(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	guard := NewQuotaGuard(3, time.Minute)
+	guard.now = func() time.Time { return now }
+	var forwarded int
+	rt := guard.Wrap(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		forwarded++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	send := func() error {
+		req, err := http.NewRequest(http.MethodPost, "https://o1.ingest.sentry.io/api/1/envelope/", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = rt.RoundTrip(req)
+		return err
+	}
+
+	var dropped int
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Second)
+		if err := send(); errors.Is(err, ErrSentryQuotaExceeded) {
+			dropped++
+		}
+	}
+	if forwarded != 3 || dropped != 7 || guard.Remaining() != 0 {
+		t.Errorf("unexpected forwarded=%d dropped=%d remaining=%d", forwarded, dropped, guard.Remaining())
+	}
+	if count := strings.Count(logs.String(), "quota exceeded"); count != 1 {
+		t.Errorf("expected the quota to be logged once, got %d\n%s", count, logs.String())
+	}
+
+	// the first request of the burst leaves the window
+	now = now.Add(51 * time.Second)
+	if remaining := guard.Remaining(); remaining != 1 {
+		t.Errorf("expected the window to slide, remaining %d", remaining)
+	}
+	if err := send(); err != nil || forwarded != 4 {
+		t.Errorf("expected the request to be forwarded %v", err)
+	}
+	if err := send(); !errors.Is(err, ErrSentryQuotaExceeded) {
+		t.Errorf("expected the request to be dropped %v", err)
+	}
+	if count := strings.Count(logs.String(), "quota exceeded"); count != 2 {
+		t.Errorf("expected the quota to be logged again once exceeded again, got %d", count)
+	}
+}
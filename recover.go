@@ -0,0 +1,105 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryEventIDHeader is the response header of the event id of a panic, see IncludeEventIDInResponse.
+const SentryEventIDHeader = "X-Sentry-Event-Id"
+
+// MiddlewareSentryRecover is a net/http middleware that sends a panic of the handler to Sentry as a SentryError500
+// with the stack of the panic, and writes a 500 response instead, see the PanicResponse options.
+// http.ErrAbortHandler is panicked again, the server aborts the response without logging it.
+// The response is not written when the handler already wrote the status code or a part of the body.
+// The event has the scope Middleware500 sets up with the same options, e.g. the HubModifiers and the DefaultSeverity.
+// Under Middleware500 the 500 response is not sent to Sentry again, see SuppressSentryCapture.
+func MiddlewareSentryRecover(opts Sentry500Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestBody := func() []byte { return nil }
+			if opts.CaptureRequestBody {
+				requestBody = RecordRequestBody(r, DefaultReplayBodyBytes)
+			}
+			recorder, status := StatusRecorder(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+				hubOrig := sentry.GetHubFromContext(r.Context())
+				if hubOrig == nil {
+					hubOrig = sentry.CurrentHub().Clone()
+				}
+				hub := HubCustomFingerprint(hubOrig, opts.FingerprintOpts)
+				setUpRequestScope(hub, r, opts, CaptureSourcePanicRecovery, requestBody)
+				err500 := SentryError500{
+					Url:        r.URL.String(),
+					Body:       fmt.Sprint(v),
+					Severity:   opts.DefaultSeverity,
+					PanicStack: string(debug.Stack()),
+				}
+				var eventID *sentry.EventID
+				if client := hub.Client(); client != nil {
+					hint := &sentry.EventHint{Context: r.Context(), RecoveredException: v, OriginalException: err500}
+					eventID = client.RecoverWithContext(r.Context(), err500, hint, hub.Scope())
+				}
+				// the panic is reported, Middleware500 must not report the 500 response too
+				SuppressSentryCapture(r.Context())
+				if status() == 0 {
+					writePanicResponse(w, opts, eventID)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+func writePanicResponse(w http.ResponseWriter, opts Sentry500Options, eventID *sentry.EventID) {
+	for key, value := range opts.PanicResponseHeaders {
+		w.Header().Set(key, value)
+	}
+	if opts.PanicResponseContentType != "" {
+		w.Header().Set("Content-Type", opts.PanicResponseContentType)
+	}
+	body := opts.PanicResponseBody
+	if opts.IncludeEventIDInResponse && eventID != nil {
+		w.Header().Set(SentryEventIDHeader, string(*eventID))
+		if isJSONContentType(opts.PanicResponseContentType) {
+			body = withSentryEventID(body, *eventID)
+		}
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	if len(body) > 0 {
+		_, _ = w.Write(body)
+	}
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// withSentryEventID adds the sentry_event_id field to a JSON object, other bodies are returned unchanged.
+func withSentryEventID(body []byte, eventID sentry.EventID) []byte {
+	var object map[string]any
+	if err := json.Unmarshal(body, &object); err != nil || object == nil {
+		return body
+	}
+	object["sentry_event_id"] = eventID
+	withID, err := json.Marshal(object)
+	if err != nil {
+		return body
+	}
+	return withID
+}
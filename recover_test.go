@@ -0,0 +1,191 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestMiddlewareSentryRecover(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.PanicResponseBody = []byte(`{"error":"internal"}`)
+	opts.PanicResponseContentType = "application/json"
+	opts.PanicResponseHeaders = map[string]string{"Cache-Control": "no-store"}
+	opts.IncludeEventIDInResponse = true
+	handler := MiddlewareSentryRecover(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("nil map"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Cache-Control") != "no-store" ||
+		rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected the panic to be captured, got %d events", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if id := rec.Header().Get(SentryEventIDHeader); id != string(event.EventID) {
+		t.Errorf("unexpected event id header %q, event %q", id, event.EventID)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "internal" || body["sentry_event_id"] != string(event.EventID) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if source := event.Tags[CaptureSourceTag]; source != string(CaptureSourcePanicRecovery) {
+		t.Errorf("unexpected capture source %q", source)
+	}
+	if m := event.Exception[len(event.Exception)-1].Mechanism; m == nil || m.Type != MechanismPanic {
+		t.Errorf("expected the panic mechanism %+v", m)
+	}
+}
+
+func createOrder(w http.ResponseWriter, r *http.Request) {
+	var orders map[string]int
+	orders["42"]++
+}
+
+func TestMiddlewareSentryRecoverPanicStack(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentryRecover(DefaultSentry500Opts)(http.HandlerFunc(createOrder))
+	req := httptest.NewRequest(http.MethodPost, "/orders/42", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected the panic to be captured, got %d events", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	e500, ok := event.Extra["sentry_error"].(SentryError500)
+	if !ok || !strings.Contains(e500.PanicStack, "createOrder") || !strings.Contains(e500.Body, "nil map") {
+		t.Errorf("expected the panic stack in the extra %+v", event.Extra)
+	}
+	if fingerprint := strings.Join(event.Fingerprint, " "); fingerprint != "/orders/-omitted- assignment to e go-sentry-middleware.createOrder" {
+		t.Errorf("unexpected fingerprint %q", fingerprint)
+	}
+}
+
+func TestPanicFrame(t *testing.T) {
+	stack := `goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+github.com/digitalmint/go-sentry-middleware.MiddlewareSentryRecover.func1.1.1()
+	/src/recover.go:40 +0x1a5
+panic({0x9a1b20?, 0xc000012345?})
+	/usr/local/go/src/runtime/panic.go:785 +0x132
+runtime.mapassign_faststr(0x0?, 0x0?, {0xa12b31?, 0x2?})
+	/usr/local/go/src/runtime/map_faststr.go:223 +0x3a
+example.com/shop/orders.(*Service).Create(0xc0000a4000, {0xa12b31, 0x2})
+	/src/orders/service.go:12 +0x45
+net/http.HandlerFunc.ServeHTTP(0x0?, {0xb3c1f0?, 0xc00014e000?}, 0x0?)
+	/usr/local/go/src/net/http/server.go:2220 +0x29
+`
+	if frame := PanicFrame(stack); frame != "orders.(*Service).Create" {
+		t.Errorf("unexpected frame %q", frame)
+	}
+	if frame := PanicFrame(""); frame != "" {
+		t.Errorf("unexpected frame %q", frame)
+	}
+}
+
+func TestMiddlewareSentryRecoverDefaults(t *testing.T) {
+	hub, _ := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentryRecover(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError || rec.Body.Len() != 0 || rec.Header().Get(SentryEventIDHeader) != "" {
+		t.Errorf("unexpected response %d %q %v", rec.Code, rec.Body, rec.Header())
+	}
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to be panicked again, got %v", v)
+		}
+	}()
+	MiddlewareSentryRecover(DefaultSentry500Opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestMiddlewareSentryRecoverUnderMiddleware500(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.DefaultSeverity = sentry.LevelFatal
+	opts.HubModifiers = []HubModifier{HubModifierFunc(func(_ context.Context, hub *sentry.Hub) {
+		hub.Scope().SetTag("tenant", "acme")
+	})}
+	handler := Middleware500(opts)(MiddlewareSentryRecover(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected the panic to be captured once, got %d events", len(transport.Events()))
+	}
+	event := transport.Events()[0]
+	if source := event.Tags[CaptureSourceTag]; source != string(CaptureSourcePanicRecovery) {
+		t.Errorf("unexpected capture source %q", source)
+	}
+	if event.Tags["tenant"] != "acme" || event.Level != sentry.LevelFatal {
+		t.Errorf("expected the scope of Middleware500, got tags %v level %q", event.Tags, event.Level)
+	}
+}
+
+func TestMiddlewareSentryRecoverAfterWrite(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultSentry500Opts
+	opts.PanicResponseBody = []byte("panic")
+	handler := MiddlewareSentryRecover(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("expected the sent response to be left alone, got %d %q", rec.Code, rec.Body)
+	}
+	if len(transport.Events()) != 1 {
+		t.Errorf("expected the panic to be captured, got %d events", len(transport.Events()))
+	}
+}
+
+func TestWithSentryEventID(t *testing.T) {
+	id := sentry.EventID("abc")
+	if got := string(withSentryEventID([]byte("plain"), id)); got != "plain" {
+		t.Errorf("unexpected %s", got)
+	}
+	if got := string(withSentryEventID([]byte(`["a"]`), id)); got != `["a"]` {
+		t.Errorf("unexpected %s", got)
+	}
+	if got := string(withSentryEventID([]byte(`{"a":1}`), id)); got != `{"a":1,"sentry_event_id":"abc"}` {
+		t.Errorf("unexpected %s", got)
+	}
+}
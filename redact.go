@@ -0,0 +1,141 @@
+package sentry
+
+import (
+	"regexp"
+)
+
+// RedactPattern replaces the matches of Regex with Replacement.
+// When Validate is set, only the matches it accepts are replaced.
+type RedactPattern struct {
+	Regex       *regexp.Regexp
+	Replacement []byte
+	Validate    func(match []byte) bool
+}
+
+// CreditCardPattern matches 13 to 19 digit card numbers, optionally grouped with dashes or spaces, that pass the Luhn check.
+var CreditCardPattern = RedactPattern{
+	Regex:       regexp.MustCompile(`\b(?:[0-9][ \-]?){12,18}[0-9]\b`),
+	Replacement: []byte("REDACTED-CARD"),
+	Validate:    luhnValid,
+}
+
+// SSNPattern matches US social security numbers written as 123-45-6789.
+var SSNPattern = RedactPattern{
+	Regex:       regexp.MustCompile(`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`),
+	Replacement: []byte("REDACTED-SSN"),
+}
+
+// DSNPattern matches Sentry DSNs.
+var DSNPattern = RedactPattern{
+	Regex:       regexp.MustCompile(`[^ '"]+sentry\.io/[^ '"]+`),
+	Replacement: []byte("REDACTED"),
+}
+
+var DefaultRedactPatterns = []RedactPattern{DSNPattern, CreditCardPattern, SSNPattern}
+
+// ipv4Address matches the dotted decimal form, each byte from 0 to 255.
+const ipv4Address = `(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])`
+
+// ipv6Regex matches the full and compressed IPv6 forms and those ending with an IPv4 address.
+// The longest match is kept so that 2001:db8::1 is not cut at the "::".
+var ipv6Regex = func() *regexp.Regexp {
+	h := `[0-9A-Fa-f]{1,4}`
+	re := regexp.MustCompile(`\b(?:` +
+		`(?:` + h + `:){7}` + h + `|` +
+		`(?:` + h + `:){1,7}:|` +
+		`(?:` + h + `:){1,6}:` + h + `|` +
+		`(?:` + h + `:){1,5}(?::` + h + `){1,2}|` +
+		`(?:` + h + `:){1,4}(?::` + h + `){1,3}|` +
+		`(?:` + h + `:){1,3}(?::` + h + `){1,4}|` +
+		`(?:` + h + `:){1,2}(?::` + h + `){1,5}|` +
+		h + `:(?::` + h + `){1,6}|` +
+		`(?:` + h + `:){6}` + ipv4Address + `|` +
+		`(?:` + h + `:){1,4}:` + ipv4Address +
+		`)|::(?:(?:ffff(?::0{1,4})?:)?` + ipv4Address + `|` + h + `(?::` + h + `){0,6})?`)
+	re.Longest()
+	return re
+}()
+
+// IPv6Pattern matches IPv6 addresses, including those ending with an IPv4 address such as ::ffff:10.0.0.1.
+// DefaultPIIPatterns use the same regex.
+var IPv6Pattern = RedactPattern{
+	Regex:       ipv6Regex,
+	Replacement: []byte("<redacted-ip>"),
+}
+
+// IPv4Pattern matches IPv4 addresses, the port of 10.0.0.1:5432 is kept.
+// DefaultPIIPatterns use the same regex.
+var IPv4Pattern = RedactPattern{
+	Regex:       regexp.MustCompile(`\b` + ipv4Address + `\b`),
+	Replacement: []byte("<redacted-ip>"),
+}
+
+// IPAddressRedactor replaces the IPv4 and IPv6 addresses of s with <redacted-ip>,
+// see UnwrapAndFilterErrorTypeConfig.ExceptionValueRedactor.
+func IPAddressRedactor(s string) string {
+	return string(RedactSensitiveData([]byte(s), []RedactPattern{IPv6Pattern, IPv4Pattern}))
+}
+
+// InternalHostRedactor returns a redactor that replaces the matches of pattern with <redacted-host>,
+// e.g. `[a-z0-9.-]+\.svc\.cluster\.local`.
+func InternalHostRedactor(pattern *regexp.Regexp) func(string) string {
+	return func(s string) string {
+		return pattern.ReplaceAllString(s, "<redacted-host>")
+	}
+}
+
+// RedactionMarginBytes is captured past the body capture limit when there are redact patterns,
+// more than the longest card number with separators.
+const RedactionMarginBytes = 64
+
+// RedactionCaptureLimit extends a body capture limit by RedactionMarginBytes when there are patterns,
+// so that a match crossing the limit, e.g. a card number, is complete when the body is redacted before it is truncated.
+// A limit of 0 means no limit and is returned unchanged.
+func RedactionCaptureLimit(limit int, patterns []RedactPattern) int {
+	if limit <= 0 || len(patterns) == 0 {
+		return limit
+	}
+	return limit + RedactionMarginBytes
+}
+
+// RedactSensitiveData applies each pattern in order.
+func RedactSensitiveData(body []byte, patterns []RedactPattern) []byte {
+	for _, pattern := range patterns {
+		if pattern.Validate == nil {
+			body = pattern.Regex.ReplaceAll(body, pattern.Replacement)
+			continue
+		}
+		body = pattern.Regex.ReplaceAllFunc(body, func(match []byte) []byte {
+			if pattern.Validate(match) {
+				return pattern.Replacement
+			}
+			return match
+		})
+	}
+	return body
+}
+
+// luhnValid checks the digits of match with the Luhn algorithm, ignoring separators.
+func luhnValid(match []byte) bool {
+	digits := make([]int, 0, len(match))
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
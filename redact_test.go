@@ -0,0 +1,84 @@
+package sentry
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestRedactSensitiveData(t *testing.T) {
+	body := []byte(`{"card":"4111-1111-1111-1111","order":"1234567890123","ssn":"078-05-1120","dsn":"https://abc@o1.ingest.sentry.io/2"}`)
+	result := string(RedactSensitiveData(body, DefaultRedactPatterns))
+	want := `{"card":"REDACTED-CARD","order":"1234567890123","ssn":"REDACTED-SSN","dsn":"REDACTED"}`
+	if result != want {
+		t.Errorf("unexpected %s", result)
+	}
+	if result := string(RedactSensitiveData([]byte("card 4012 8888 8888 1881"), DefaultRedactPatterns)); result != "card REDACTED-CARD" {
+		t.Errorf("unexpected %s", result)
+	}
+}
+
+// ipAddressTests are checked against IPAddressRedactor and DefaultPIIPatterns, <ip> is the replacement.
+var ipAddressTests = []struct {
+	value string
+	want  string
+}{
+	{"dial tcp 10.0.0.1:5432: connect: connection refused", "dial tcp <ip>:5432: connect: connection refused"},
+	{"dial tcp [2001:db8::1]:443: i/o timeout", "dial tcp [<ip>]:443: i/o timeout"},
+	{"dial tcp [2001:db8:85a3::8a2e:370:7334]:443: refused", "dial tcp [<ip>]:443: refused"},
+	{"from 2001:0db8:0000:0000:0000:ff00:0042:8329 refused", "from <ip> refused"},
+	{"from fe80::1: connection reset", "from <ip>: connection reset"},
+	{"listen ::1 and 0.0.0.0", "listen <ip> and <ip>"},
+	{"peer ::ffff:192.168.1.20 closed", "peer <ip> closed"},
+	{"peer ::ffff:10.0.0.1:5432 closed", "peer <ip>:5432 closed"},
+	{"timeout at 12:30:45 after 999.1.1.1", "timeout at 12:30:45 after 999.1.1.1"},
+	{"version 300.1.2.3", "version 300.1.2.3"},
+}
+
+func TestIPAddressRedactor(t *testing.T) {
+	for _, test := range ipAddressTests {
+		want := strings.ReplaceAll(test.want, "<ip>", "<redacted-ip>")
+		if got := IPAddressRedactor(test.value); got != want {
+			t.Errorf("IPAddressRedactor %q: expected %q, got %q", test.value, want, got)
+		}
+	}
+}
+
+func TestDefaultPIIPatternsIPAddresses(t *testing.T) {
+	redact := PIIRedactBeforeSend(DefaultPIIPatterns, "<ip>")
+	for _, test := range ipAddressTests {
+		if got := redact(&sentry.Event{Message: test.value}, nil).Message; got != test.want {
+			t.Errorf("DefaultPIIPatterns %q: expected %q, got %q", test.value, test.want, got)
+		}
+	}
+}
+
+func TestExceptionValueRedactor(t *testing.T) {
+	hostRedactor := InternalHostRedactor(regexp.MustCompile(`[a-z0-9.-]+\.svc\.cluster\.local`))
+	beforeSend := SentryBeforeSendUnwrapAndFilterErrorType(UnwrapAndFilterErrorTypeConfig{
+		ExceptionValueRedactor: func(value string) string { return hostRedactor(IPAddressRedactor(value)) },
+	})
+	err := fmt.Errorf("orders-db.payments.svc.cluster.local: %w", &net.OpError{
+		Op: "dial", Net: "tcp", Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5432}, Err: fmt.Errorf("connection refused"),
+	})
+	event := sentry.NewEvent()
+	event.SetException(err, 10)
+	event = beforeSend(event, &sentry.EventHint{OriginalException: err})
+
+	if len(event.Exception) < 2 {
+		t.Fatalf("expected the wrapped exceptions %v", event.Exception)
+	}
+	for _, exception := range event.Exception {
+		if strings.Contains(exception.Value, "10.0.0.1") || strings.Contains(exception.Value, "svc.cluster.local") {
+			t.Errorf("exception value not redacted %q", exception.Value)
+		}
+	}
+	want := "<redacted-host>: dial tcp <redacted-ip>:5432: connection refused"
+	if last := event.Exception[len(event.Exception)-1]; last.Value != want {
+		t.Errorf("expected %q, got %q", want, last.Value)
+	}
+}
@@ -0,0 +1,215 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ReplayExtraKey is the event extra holding the map returned by RequestReplay.
+const ReplayExtraKey = "replay"
+
+// DefaultReplayBodyBytes is the most of the request body RecordRequestBody keeps.
+const DefaultReplayBodyBytes = 64 * 1024
+
+const filteredValue = "[Filtered]"
+
+// ReplaySensitiveHeaders are headers whose value is replaced with "[Filtered]" in the replay.
+var ReplaySensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key", "X-Auth-Token"}
+
+// ReplaySensitiveParams are query parameters whose value is replaced with "[Filtered]" in the replay.
+var ReplaySensitiveParams = []string{"access_token", "api_key", "key", "password", "secret", "token"}
+
+var ErrInvalidReplay = errors.New("invalid request replay")
+
+// RecordRequestBody keeps a copy of up to maxBytes of the request body as the handler reads it.
+// The returned function gives what was read so far.
+func RecordRequestBody(r *http.Request, maxBytes int) func() []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return func() []byte { return nil }
+	}
+	buf := &cappedBuffer{limit: maxBytes}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(r.Body, buf), r.Body}
+	return buf.Bytes
+}
+
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	if room := cb.limit - cb.Len(); room < len(p) {
+		cb.Buffer.Write(p[:max(room, 0)])
+	} else {
+		cb.Buffer.Write(p)
+	}
+	return len(p), nil
+}
+
+// RequestReplay describes the request with enough detail to send it again, see ReplayToCurl.
+// Sensitive headers and query parameters are filtered, the body is redacted with DefaultRedactPatterns.
+// A nil body is left out. The map is JSON-serializable.
+func RequestReplay(r *http.Request, body []byte) map[string]any {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := *r.URL
+	if u.Scheme == "" {
+		u.Scheme = scheme
+	}
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	replay := map[string]any{
+		"method":  r.Method,
+		"url":     SanitizeURL(&u),
+		"headers": filterValues(r.Header, func(key string) bool { return slices.Contains(ReplaySensitiveHeaders, key) }),
+		"query": filterValues(r.URL.Query(), func(key string) bool {
+			return slices.Contains(ReplaySensitiveParams, strings.ToLower(key))
+		}),
+	}
+	if body != nil {
+		replay["body"] = string(RedactSensitiveData(body, DefaultRedactPatterns))
+	}
+	return replay
+}
+
+// RequestData returns the sanitized body of a form or JSON request for the data of the event request.
+// Form fields in ReplaySensitiveParams are filtered, a JSON body is redacted with DefaultRedactPatterns.
+// Other content types return "".
+func RequestData(r *http.Request, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return ""
+		}
+		return encodeSorted(filterValues(form, func(key string) bool {
+			return slices.Contains(ReplaySensitiveParams, strings.ToLower(key))
+		}))
+	case "application/json":
+		return strings.ToValidUTF8(string(RedactSensitiveData(body, DefaultRedactPatterns)), "")
+	}
+	return ""
+}
+
+// RequestDataModifier sets the data of the event request to RequestData.
+func RequestDataModifier(body func() []byte) HubModifier {
+	return HubModifierFunc(func(ctx context.Context, hub *sentry.Hub) {
+		r := RequestFromContext(ctx)
+		if r == nil {
+			return
+		}
+		data := RequestData(r, body())
+		if data == "" {
+			return
+		}
+		hub.Scope().AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			if event.Request != nil {
+				event.Request.Data = data
+			}
+			return event
+		})
+	})
+}
+
+func filterValues(values map[string][]string, sensitive func(string) bool) map[string][]string {
+	filtered := make(map[string][]string, len(values))
+	for key, vs := range values {
+		if sensitive(key) {
+			filtered[key] = []string{filteredValue}
+			continue
+		}
+		filtered[key] = slices.Clone(vs)
+	}
+	return filtered
+}
+
+// ReplayToCurl converts the map of RequestReplay to a curl command.
+// It accepts the event extra holding the replay as well as the replay itself,
+// after a JSON round trip or not.
+func ReplayToCurl(extra map[string]any) (string, error) {
+	replay := extra
+	if nested, ok := extra[ReplayExtraKey].(map[string]any); ok {
+		replay = nested
+	}
+	method, _ := replay["method"].(string)
+	rawURL, _ := replay["url"].(string)
+	if method == "" || rawURL == "" {
+		return "", fmt.Errorf("%w: missing method or url", ErrInvalidReplay)
+	}
+	// normalize the value types lost by a JSON round trip
+	var decoded struct {
+		Headers map[string][]string `json:"headers"`
+		Query   map[string][]string `json:"query"`
+		Body    *string             `json:"body"`
+	}
+	raw, err := json.Marshal(replay)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidReplay, err)
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidReplay, err)
+	}
+
+	if query := encodeSorted(decoded.Query); query != "" {
+		rawURL += "?" + query
+	}
+	parts := []string{"curl", "-X", shellQuote(method), shellQuote(rawURL)}
+	for _, key := range sortedKeys(decoded.Headers) {
+		for _, value := range decoded.Headers[key] {
+			parts = append(parts, "-H", shellQuote(key+": "+value))
+		}
+	}
+	if decoded.Body != nil {
+		parts = append(parts, "--data-raw", shellQuote(*decoded.Body))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func encodeSorted(values map[string][]string) string {
+	var query []string
+	for _, key := range sortedKeys(values) {
+		for _, value := range values[key] {
+			query = append(query, queryEscape(key)+"="+queryEscape(value))
+		}
+	}
+	return strings.Join(query, "&")
+}
+
+// queryEscape keeps "[Filtered]" readable in the command.
+func queryEscape(s string) string {
+	if s == filteredValue {
+		return s
+	}
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,78 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestReplayToCurl(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/orders?token=abc&page=2", strings.NewReader(`{"note":"it's late"}`))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Content-Type", "application/json")
+	body := RecordRequestBody(r, DefaultReplayBodyBytes)
+	if _, err := io.ReadAll(r.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := RequestReplay(r, body())
+	// the event extra is sent as JSON
+	raw, err := json.Marshal(map[string]any{ReplayExtraKey: replay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extra map[string]any
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `curl -X 'POST' 'http://example.com/orders?page=2&token=[Filtered]'` +
+		` -H 'Authorization: [Filtered]' -H 'Content-Type: application/json' --data-raw '{"note":"it'\''s late"}'`
+	for _, input := range []map[string]any{replay, extra} {
+		got, err := ReplayToCurl(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("unexpected command\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestRecordRequestBodyLimit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+	body := RecordRequestBody(r, 4)
+	read, _ := io.ReadAll(r.Body)
+	if string(read) != "0123456789" || string(body()) != "0123" {
+		t.Errorf("unexpected bodies %q %q", read, body())
+	}
+}
+
+func TestReplayToCurlInvalid(t *testing.T) {
+	if _, err := ReplayToCurl(map[string]any{"url": "http://example.com"}); !errors.Is(err, ErrInvalidReplay) {
+		t.Errorf("expected ErrInvalidReplay, got %v", err)
+	}
+}
+
+func TestRequestData(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/x-www-form-urlencoded", "name=Ada&password=hunter2", "name=Ada&password=[Filtered]"},
+		{"application/json; charset=utf-8", `{"card":"4111 1111 1111 1111"}`, `{"card":"REDACTED-CARD"}`},
+		{"text/plain", "hello", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Content-Type", tt.contentType)
+		if got := RequestData(r, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
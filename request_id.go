@@ -0,0 +1,56 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// RequestIDHeader is the header read and set by RequestIDMiddleware.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDTag is the tag set by RequestIDMiddleware.
+const RequestIDTag = "request_id"
+
+// DefaultRequestIDGenerator returns IDs made of the current time and a random number.
+func DefaultRequestIDGenerator() string {
+	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Uint32())
+}
+
+// RequestIDMiddleware keeps the X-Request-ID of the request or generates one when it is missing, nil means
+// DefaultRequestIDGenerator. The ID is set on the request header for RequestTagModifier, on the response header,
+// in the context for RequestIDFromContext, and as the request_id tag of the hub of the context and of
+// the 500 middlewares through WithSentryTags.
+func RequestIDMiddleware(generator func() string) func(http.Handler) http.Handler {
+	if generator == nil {
+		generator = DefaultRequestIDGenerator
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = generator()
+				r = r.Clone(r.Context())
+				r.Header.Set(RequestIDHeader, id)
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), requestIDKey, id)
+			ctx = WithSentryTags(ctx, map[string]string{RequestIDTag: id})
+			if hub := sentry.GetHubFromContext(ctx); hub != nil {
+				hub.Scope().SetTag(RequestIDTag, id)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the ID stored by RequestIDMiddleware or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
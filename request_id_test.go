@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	hub, _ := sentrytest.NewRecordingHub(t)
+	var ctxID, headerID string
+	var ctxTags map[string]string
+	handler := RequestIDMiddleware(func() string { return "generated" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = RequestIDFromContext(r.Context())
+		headerID = r.Header.Get(RequestIDHeader)
+		ctxTags = SentryTagsFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(sentry.SetHubOnContext(context.Background(), hub))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if ctxID != "generated" || headerID != "generated" || rec.Header().Get(RequestIDHeader) != "generated" {
+		t.Errorf("unexpected ids %q %q %q", ctxID, headerID, rec.Header().Get(RequestIDHeader))
+	}
+	if ctxTags[RequestIDTag] != "generated" || sentrytest.CapturedScope(hub).Tags()[RequestIDTag] != "generated" {
+		t.Errorf("expected the request_id tag %v %v", ctxTags, sentrytest.CapturedScope(hub).Tags())
+	}
+	if req.Header.Get(RequestIDHeader) != "" {
+		t.Error("expected the incoming request not to be modified")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "from-gateway")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if ctxID != "from-gateway" || rec.Header().Get(RequestIDHeader) != "from-gateway" {
+		t.Errorf("expected the incoming id to be kept, got %q", ctxID)
+	}
+}
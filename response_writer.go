@@ -0,0 +1,160 @@
+package sentry
+
+import (
+	"net/http"
+)
+
+// statusCaptureResponseWriter is a custom response writer to capture the status code.
+type statusCaptureResponseWriter struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
+	body         []byte
+	// maxBytes stops body capture once the body is known to need truncation, 0 means no limit
+	maxBytes         int
+	skipContentTypes []string
+	// skipBody is decided from the Content-Type when the header is written
+	skipBody      bool
+	headerChecked bool
+	// lazy skips the body of responses that are not 500
+	lazy bool
+	// flushed is set when the handler flushed the response, see IsChunkedResponse
+	flushed bool
+}
+
+// WriteHeader captures the status code before it's written.
+func (sw *statusCaptureResponseWriter) WriteHeader(code int) {
+	sw.statusCode = code
+	sw.checkContentType()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusCaptureResponseWriter) checkContentType() {
+	if sw.headerChecked {
+		return
+	}
+	sw.headerChecked = true
+	sw.skipBody = (sw.lazy && sw.statusCode != 500) ||
+		MatchContentType(sw.Header().Get("Content-Type"), sw.skipContentTypes)
+}
+
+// Write captures the body before it's written.
+func (sw *statusCaptureResponseWriter) Write(b []byte) (int, error) {
+	// Write without WriteHeader sends the headers now with an implicit 200
+	if sw.statusCode == 0 {
+		sw.statusCode = http.StatusOK
+	}
+	sw.checkContentType()
+	if sw.skipBody {
+		return sw.write(b)
+	}
+	captured := b
+	if sw.maxBytes > 0 {
+		// one byte past the limit is kept so that truncation can find the rune boundary
+		room := sw.maxBytes + 1 - len(sw.body)
+		if room < 0 {
+			room = 0
+		}
+		if room < len(captured) {
+			captured = captured[:room]
+		}
+	}
+	sw.body = append(sw.body, captured...)
+	return sw.write(b)
+}
+
+// truncated reports whether body is missing the end of the response body.
+func (sw *statusCaptureResponseWriter) truncated() bool {
+	return sw.maxBytes > 0 && len(sw.body) > sw.maxBytes
+}
+
+func (sw *statusCaptureResponseWriter) write(b []byte) (int, error) {
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytesWritten += int64(n)
+	return n, err
+}
+
+// StatusRecorder wraps w to record the status code written by the handler, 200 for a Write without WriteHeader
+// and 0 when nothing was written.
+// The returned writer keeps the optional interfaces of w.
+func StatusRecorder(w http.ResponseWriter) (http.ResponseWriter, func() int) {
+	sw := &statusCaptureResponseWriter{ResponseWriter: w, skipBody: true, headerChecked: true}
+	return sw.withOptionalInterfaces(), func() int { return sw.statusCode }
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (sw *statusCaptureResponseWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// flushRecorder records the flushes of the handler before passing them on.
+type flushRecorder struct {
+	sw *statusCaptureResponseWriter
+	http.Flusher
+}
+
+func (fr flushRecorder) Flush() {
+	fr.sw.flushed = true
+	fr.Flusher.Flush()
+}
+
+// withOptionalInterfaces returns the writer extended with the optional interfaces
+// (http.Flusher, http.Hijacker, http.CloseNotifier) that the underlying writer implements.
+// Handlers check for these with type assertions, e.g. SSE handlers need to flush,
+// so the wrapper must not implement an interface the underlying writer lacks.
+//
+//nolint:staticcheck // http.CloseNotifier is deprecated but still used by handlers
+func (sw *statusCaptureResponseWriter) withOptionalInterfaces() http.ResponseWriter {
+	var flusher http.Flusher
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher = flushRecorder{sw: sw, Flusher: f}
+	}
+	isFlusher := flusher != nil
+	hijacker, isHijacker := sw.ResponseWriter.(http.Hijacker)
+	closeNotifier, isCloseNotifier := sw.ResponseWriter.(http.CloseNotifier)
+
+	switch {
+	case isFlusher && isHijacker && isCloseNotifier:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.CloseNotifier
+		}{sw, flusher, hijacker, closeNotifier}
+	case isFlusher && isHijacker:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{sw, flusher, hijacker}
+	case isFlusher && isCloseNotifier:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Flusher
+			http.CloseNotifier
+		}{sw, flusher, closeNotifier}
+	case isHijacker && isCloseNotifier:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Hijacker
+			http.CloseNotifier
+		}{sw, hijacker, closeNotifier}
+	case isFlusher:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Flusher
+		}{sw, flusher}
+	case isHijacker:
+		return struct {
+			*statusCaptureResponseWriter
+			http.Hijacker
+		}{sw, hijacker}
+	case isCloseNotifier:
+		return struct {
+			*statusCaptureResponseWriter
+			http.CloseNotifier
+		}{sw, closeNotifier}
+	default:
+		return sw
+	}
+}
@@ -0,0 +1,105 @@
+package sentry
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type plainWriter struct {
+	header http.Header
+}
+
+func (pw *plainWriter) Header() http.Header         { return pw.header }
+func (pw *plainWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (pw *plainWriter) WriteHeader(_ int)           {}
+
+type flushWriter struct {
+	plainWriter
+	flushed bool
+}
+
+func (fw *flushWriter) Flush() { fw.flushed = true }
+
+type hijackWriter struct{ plainWriter }
+
+func (hw *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+type allWriter struct{ flushWriter }
+
+func (aw *allWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (aw *allWriter) CloseNotify() <-chan bool                     { return make(chan bool) }
+
+func interfacesOf(w http.ResponseWriter) (isFlusher, isHijacker, isCloseNotifier bool) {
+	_, isFlusher = w.(http.Flusher)
+	_, isHijacker = w.(http.Hijacker)
+	_, isCloseNotifier = w.(http.CloseNotifier) //nolint:staticcheck
+	return
+}
+
+func TestWriterOptionalInterfaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		underlying http.ResponseWriter
+		flusher    bool
+		hijacker   bool
+		notifier   bool
+	}{
+		{"plain", &plainWriter{}, false, false, false},
+		{"flusher", &flushWriter{}, true, false, false},
+		{"hijacker", &hijackWriter{}, false, true, false},
+		{"all", &allWriter{}, true, true, true},
+	}
+	for _, tt := range tests {
+		wrapped := (&statusCaptureResponseWriter{ResponseWriter: tt.underlying}).withOptionalInterfaces()
+		isFlusher, isHijacker, isCloseNotifier := interfacesOf(wrapped)
+		if isFlusher != tt.flusher || isHijacker != tt.hijacker || isCloseNotifier != tt.notifier {
+			t.Errorf("%s: got flusher=%v hijacker=%v closenotifier=%v", tt.name, isFlusher, isHijacker, isCloseNotifier)
+		}
+	}
+
+	underlying := &flushWriter{}
+	wrapped := (&statusCaptureResponseWriter{ResponseWriter: underlying}).withOptionalInterfaces()
+	wrapped.(http.Flusher).Flush()
+	if !underlying.flushed {
+		t.Errorf("flush was not delegated")
+	}
+}
+
+func TestStatusCaptureResponseWriterLazy(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusInternalServerError} {
+		sw := &statusCaptureResponseWriter{ResponseWriter: httptest.NewRecorder(), lazy: true}
+		sw.WriteHeader(status)
+		_, _ = sw.Write([]byte("body"))
+		if captured := sw.body != nil; captured != (status == http.StatusInternalServerError) {
+			t.Errorf("%d: captured %q", status, sw.body)
+		}
+	}
+
+	sw := &statusCaptureResponseWriter{ResponseWriter: httptest.NewRecorder(), lazy: true}
+	_, _ = sw.Write([]byte("implicit 200"))
+	if sw.body != nil {
+		t.Errorf("captured %q", sw.body)
+	}
+}
+
+func TestStatusRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(http.ResponseWriter)
+		want    int
+	}{
+		{"nothing written", func(http.ResponseWriter) {}, 0},
+		{"WriteHeader", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) }, http.StatusNotFound},
+		{"Write without WriteHeader", func(w http.ResponseWriter) { _, _ = w.Write([]byte("partial")) }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		recorder, status := StatusRecorder(httptest.NewRecorder())
+		tt.handler(recorder)
+		if status() != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, status())
+		}
+	}
+}
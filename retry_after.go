@@ -0,0 +1,65 @@
+package sentry
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// retryAfter keeps the deadline of the last 429 response of Sentry, shared by the copies of a LogSentrySendFailures.
+type retryAfter struct {
+	// until holds a time.Time
+	until atomic.Value
+	now   func() time.Time
+}
+
+func newRetryAfter() *retryAfter {
+	return &retryAfter{now: time.Now}
+}
+
+// blocked reports whether the deadline is not passed yet, a passed deadline is reset.
+func (ra *retryAfter) blocked() (time.Time, bool) {
+	until, _ := ra.until.Load().(time.Time)
+	if until.IsZero() {
+		return until, false
+	}
+	if !ra.now().Before(until) {
+		ra.until.CompareAndSwap(until, time.Time{})
+		return until, false
+	}
+	return until, true
+}
+
+// observe stores the deadline of a 429 response with a Retry-After header.
+// It returns true when requests were not blocked before, the block is then new and should be logged.
+func (ra *retryAfter) observe(resp *http.Response) (time.Time, bool) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	now := ra.now()
+	until, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	if !ok {
+		return time.Time{}, false
+	}
+	previous, _ := ra.until.Swap(until).(time.Time)
+	return until, !previous.After(now)
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) (time.Time, bool) {
+	if header == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds <= 0 {
+			return time.Time{}, false
+		}
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil || !date.After(now) {
+		return time.Time{}, false
+	}
+	return date, true
+}
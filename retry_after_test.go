@@ -0,0 +1,95 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogSentrySendFailuresRespectRetryAfter(t *testing.T) {
+	var hits atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logged []ErrSentryRoundTrip
+	lsf := NewLogSentrySendFailures(http.DefaultTransport)
+	lsf.RespectRetryAfter = true
+	lsf.retryAfter.now = func() time.Time { return now }
+	lsf.ErrorHandler = func(_ context.Context, err ErrSentryRoundTrip) {
+		if strings.HasPrefix(err.Msg, "Sentry rate limit") {
+			logged = append(logged, err)
+		}
+	}
+	send := func() error {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"message":"m"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := lsf.RoundTrip(req)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+	for _, elapsed := range []time.Duration{0, time.Second, 1999 * time.Millisecond} {
+		now = now.Add(elapsed)
+		var esrt ErrSentryRoundTrip
+		if err := send(); !errors.As(err, &esrt) || esrt.Status != http.StatusTooManyRequests {
+			t.Errorf("expected the request to be blocked, got %v", err)
+		}
+		now = now.Add(-elapsed)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("blocked requests must not be sent, server hit %d times", hits.Load())
+	}
+	if len(logged) != 1 {
+		t.Errorf("expected the rate limit to be logged once %v", logged)
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := send(); err != nil {
+		t.Errorf("expected the block to be reset %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected the request to be sent, server hit %d times", hits.Load())
+	}
+	if _, failed := lsf.Stats(); failed != 4 {
+		t.Errorf("expected the 429 and the blocked requests to be counted as failed %d", failed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Time
+		ok     bool
+	}{
+		{"2", now.Add(2 * time.Second), true},
+		{now.Add(time.Minute).Format(http.TimeFormat), now.Add(time.Minute), true},
+		{"", time.Time{}, false},
+		{"0", time.Time{}, false},
+		{"soon", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, ok := parseRetryAfter(test.header, now)
+		if ok != test.ok || !got.Equal(test.want) {
+			t.Errorf("%q: unexpected %v %v", test.header, got, ok)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type safeGoConfig struct {
+	level     sentry.Level
+	extra     map[string]any
+	maxPanics int
+}
+
+// SafeGoOption configures SafeGo.
+type SafeGoOption func(*safeGoConfig)
+
+// WithPanicLevel sets the level of the panic events, fatal by default.
+func WithPanicLevel(level sentry.Level) SafeGoOption {
+	return func(config *safeGoConfig) {
+		config.level = level
+	}
+}
+
+// WithExtraContext adds extra data to the panic events, e.g. the id of the job run by the goroutine.
+func WithExtraContext(extra map[string]any) SafeGoOption {
+	return func(config *safeGoConfig) {
+		config.extra = extra
+	}
+}
+
+// WithMaxPanics runs fn again after a panic until it returns or has panicked n times, 1 by default.
+// Values below 1 are ignored.
+func WithMaxPanics(n int) SafeGoOption {
+	return func(config *safeGoConfig) {
+		if n > 0 {
+			config.maxPanics = n
+		}
+	}
+}
+
+// SafeGo runs fn in a new goroutine with a clone of the hub of ctx, or of the current hub, in its context.
+// A panic of fn is recovered and sent to the cloned hub instead of crashing the process.
+func SafeGo(ctx context.Context, fn func(context.Context), opts ...SafeGoOption) {
+	config := safeGoConfig{level: sentry.LevelFatal, maxPanics: 1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	go func() {
+		for panics := 0; panics < config.maxPanics; panics++ {
+			if !runCapturingPanic(ctx, hub, fn, config) {
+				return
+			}
+		}
+	}()
+}
+
+// runCapturingPanic returns true when fn panicked.
+func runCapturingPanic(ctx context.Context, hub *sentry.Hub, fn func(context.Context), config safeGoConfig) (panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			panicked = true
+			hub.WithScope(func(scope *sentry.Scope) {
+				scope.SetLevel(config.level)
+				scope.SetExtras(config.extra)
+				hub.RecoverWithContext(ctx, v)
+			})
+		}
+	}()
+	fn(ctx)
+	return false
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+// waitForEvents polls the transport as SafeGo does not wait for the goroutine.
+func waitForEvents(t *testing.T, transport *sentrytest.EventRecorder, n int) []*sentry.Event {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		events := transport.Events()
+		if len(events) >= n {
+			return events
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d events", n)
+	return nil
+}
+
+func TestSafeGo(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	var goroutineHub *sentry.Hub
+	SafeGo(ctx, func(ctx context.Context) {
+		goroutineHub = sentry.GetHubFromContext(ctx)
+		panic("background job failed")
+	}, WithPanicLevel(sentry.LevelError), WithExtraContext(map[string]any{"job": "reindex"}))
+
+	events := waitForEvents(t, transport, 1)
+	if goroutineHub == hub || goroutineHub == nil {
+		t.Errorf("expected the goroutine to get a clone of the hub")
+	}
+	event := events[0]
+	if event.Level != sentry.LevelError || event.Extra["job"] != "reindex" {
+		t.Errorf("unexpected event %s %v", event.Level, event.Extra)
+	}
+	if event.Message != "background job failed" {
+		t.Errorf("expected the panic value in the event %q", event.Message)
+	}
+}
+
+func TestSafeGoMaxPanics(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	var runs atomic.Int64
+	done := make(chan struct{})
+	SafeGo(ctx, func(context.Context) {
+		if runs.Add(1) == 3 {
+			close(done)
+			return
+		}
+		panic("flaky")
+	}, WithMaxPanics(5))
+	<-done
+	if events := waitForEvents(t, transport, 2); len(events) != 2 {
+		t.Errorf("expected a panic event per failed run, got %d", len(events))
+	}
+
+	runs.Store(0)
+	SafeGo(ctx, func(context.Context) {
+		runs.Add(1)
+		panic("always")
+	}, WithMaxPanics(2))
+	waitForEvents(t, transport, 4)
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != 2 {
+		t.Errorf("expected fn to stop after 2 panics, ran %d times", runs.Load())
+	}
+}
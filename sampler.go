@@ -0,0 +1,96 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TypeSampleRule samples the errors whose type contains TypePattern at Rate, between 0 and 1.
+type TypeSampleRule struct {
+	TypePattern string
+	Rate        float64
+}
+
+// DefaultTypeSampleRules keep 1% of the timeouts and cancellations, which spike during outages.
+var DefaultTypeSampleRules = []TypeSampleRule{
+	{TypePattern: "Timeout", Rate: 0.01},
+	{TypePattern: "Canceled", Rate: 0.01},
+}
+
+// TypeBasedSampler is a SampleFunc of the middlewares that applies the rate of the first rule
+// matching the type of the request error, errors without a matching rule are always captured.
+// The request error is the one recorded with RecordRequestError, or else the error of the request context.
+// Its type is the most specific type found by unwrapping generic wrappers, errors whose Timeout method
+// reports true are typed "Timeout" and context.Canceled is typed "context.Canceled".
+func TypeBasedSampler(rules []TypeSampleRule) func(*http.Request, int) float64 {
+	return func(r *http.Request, _ int) float64 {
+		err := RequestError(r.Context())
+		if err == nil {
+			return 1
+		}
+		typ := samplingErrorType(err)
+		for _, rule := range rules {
+			if strings.Contains(typ, rule.TypePattern) {
+				return rule.Rate
+			}
+		}
+		return 1
+	}
+}
+
+// RequestError is the error recorded with RecordRequestError, or else the error of the context, see PreFilterFunc.
+func RequestError(ctx context.Context) error {
+	if err := RequestErrorFromContext(ctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func samplingErrorType(err error) string {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "context.Canceled"
+	case errors.As(err, &timeout) && timeout.Timeout():
+		return "Timeout"
+	}
+	if typ := unwrapToSpecificError(err, FilterErrorTypes(), 0); typ != nil {
+		return *typ
+	}
+	return ""
+}
+
+type requestErrorRecorder struct {
+	mu  sync.Mutex
+	err error
+}
+
+// WithRequestErrorRecorder lets RecordRequestError called on a derived context be seen with this context.
+// The middlewares call it before the handler.
+func WithRequestErrorRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestErrorKey, &requestErrorRecorder{})
+}
+
+// RecordRequestError keeps the error that failed the request for the middlewares, for example for TypeBasedSampler.
+// It does nothing without WithRequestErrorRecorder.
+func RecordRequestError(ctx context.Context, err error) {
+	if recorder, ok := ctx.Value(requestErrorKey).(*requestErrorRecorder); ok {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.err = err
+	}
+}
+
+// RequestErrorFromContext returns the error recorded with RecordRequestError or nil.
+func RequestErrorFromContext(ctx context.Context) error {
+	recorder, ok := ctx.Value(requestErrorKey).(*requestErrorRecorder)
+	if !ok {
+		return nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.err
+}
@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestTypeBasedSampler(t *testing.T) {
+	sampler := TypeBasedSampler(append(DefaultTypeSampleRules, TypeSampleRule{TypePattern: "testErr", Rate: 0.5}))
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want float64
+	}{
+		{"no error", context.Background(), nil, 1},
+		{"timeout", context.Background(), fmt.Errorf("calling billing: %w", timeoutError{}), 0.01},
+		{"canceled request", canceledCtx, nil, 0.01},
+		{"recorded type", context.Background(), fmt.Errorf("wrapped: %w", testErr{}), 0.5},
+		{"no rule", context.Background(), errors.New("boom"), 1},
+	}
+	for _, tt := range tests {
+		ctx := WithRequestErrorRecorder(tt.ctx)
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		if tt.err != nil {
+			RecordRequestError(r.Context(), tt.err)
+		}
+		if got := sampler(r, http.StatusInternalServerError); got != tt.want {
+			t.Errorf("%s: got rate %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sentry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ScopeAccumulator collects scope data from several goroutines and applies it to a hub in one step.
+// This is useful for job processors that learn about the job early but only capture errors later.
+type ScopeAccumulator struct {
+	mu    sync.Mutex
+	tags  map[string]string
+	extra map[string]any
+	user  *sentry.User
+}
+
+// NewScopeAccumulator stores a new ScopeAccumulator in the context.
+func NewScopeAccumulator(ctx context.Context) (context.Context, *ScopeAccumulator) {
+	sa := &ScopeAccumulator{
+		tags:  map[string]string{},
+		extra: map[string]any{},
+	}
+	return context.WithValue(ctx, scopeAccumulatorKey, sa), sa
+}
+
+// ScopeAccumulatorFromContext returns the ScopeAccumulator stored by NewScopeAccumulator or nil.
+func ScopeAccumulatorFromContext(ctx context.Context) *ScopeAccumulator {
+	sa, _ := ctx.Value(scopeAccumulatorKey).(*ScopeAccumulator)
+	return sa
+}
+
+func (sa *ScopeAccumulator) SetTag(key, value string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.tags[key] = value
+}
+
+func (sa *ScopeAccumulator) SetExtra(key string, value any) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.extra[key] = value
+}
+
+func (sa *ScopeAccumulator) SetUser(user sentry.User) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.user = &user
+}
+
+// Apply sets everything accumulated so far on the hub scope.
+func (sa *ScopeAccumulator) Apply(hub *sentry.Hub) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetTags(sa.tags)
+		scope.SetExtras(sa.extra)
+		if sa.user != nil {
+			scope.SetUser(*sa.user)
+		}
+	})
+}
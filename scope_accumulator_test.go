@@ -0,0 +1,42 @@
+package sentry
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestScopeAccumulator(t *testing.T) {
+	if ScopeAccumulatorFromContext(context.Background()) != nil {
+		t.Fatal("expected no accumulator")
+	}
+	ctx, _ := NewScopeAccumulator(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sa := ScopeAccumulatorFromContext(ctx)
+			sa.SetTag("tag"+strconv.Itoa(i), "value")
+			sa.SetExtra("extra"+strconv.Itoa(i), i)
+		}(i)
+	}
+	wg.Wait()
+	ScopeAccumulatorFromContext(ctx).SetUser(sentry.User{ID: "job-user"})
+
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	ScopeAccumulatorFromContext(ctx).Apply(hub)
+
+	recorder := sentrytest.CapturedScope(hub)
+	if len(recorder.Tags()) != 10 || len(recorder.Extra()) != 10 {
+		t.Errorf("unexpected tags %v extra %v", recorder.Tags(), recorder.Extra())
+	}
+	if recorder.User().ID != "job-user" {
+		t.Errorf("unexpected user %v", recorder.User())
+	}
+}
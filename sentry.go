@@ -5,14 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 )
@@ -20,25 +27,146 @@ import (
 type SentryError500 struct {
 	Url  string
 	Body string
+	// ErrorName is a decoded error name, when set it is used for grouping instead of the body
+	ErrorName string
+	// Severity is the level of the event when set, it takes precedence over the level of the scope
+	Severity sentry.Level
+	// PanicStack is the debug.Stack of a panic that caused the 500, see MiddlewareSentryRecover.
+	// The function that panicked is then part of the fingerprint.
+	PanicStack string
 }
 
 func (e500 SentryError500) Error() string {
 	return "500 " + e500.Url + ":" + e500.Body
 }
 
+type sentryError500JSON struct {
+	Url        string       `json:"url"`
+	Body       string       `json:"body"`
+	ErrorName  string       `json:"error_name,omitempty"`
+	Severity   sentry.Level `json:"severity,omitempty"`
+	PanicStack string       `json:"panic_stack,omitempty"`
+}
+
+// MarshalJSON is used when the error is in the event extra, see HubCustomFingerprint.
+func (e500 SentryError500) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sentryError500JSON{
+		Url:        e500.Url,
+		Body:       e500.Body,
+		ErrorName:  e500.ErrorName,
+		Severity:   e500.Severity,
+		PanicStack: e500.PanicStack,
+	})
+}
+
+func (e500 *SentryError500) UnmarshalJSON(data []byte) error {
+	var decoded sentryError500JSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*e500 = SentryError500{
+		Url:        decoded.Url,
+		Body:       decoded.Body,
+		ErrorName:  decoded.ErrorName,
+		Severity:   decoded.Severity,
+		PanicStack: decoded.PanicStack,
+	}
+	return nil
+}
+
 func (e500 SentryError500) Fingerprint(_ []string) ([]string, error) {
-	message := e500.Body
-	if len(e500.Body) > 15 {
-		message = e500.Body[0:15]
+	message := TruncateToValidUTF8(e500.Body, 15)
+	if e500.ErrorName != "" {
+		message = e500.ErrorName
 	}
 	u, err := url.Parse(e500.Url)
 	if err != nil {
 		return nil, err
 	}
 	newPath := NormalizeUrlPathForSentry(u, "")
+	if frame := PanicFrame(e500.PanicStack); frame != "" {
+		return []string{newPath, message, frame}, nil
+	}
 	return []string{newPath, message}, nil
 }
 
+// PanicFrame returns the function that panicked in a debug.Stack, e.g. "orders.(*Service).Create",
+// the package is the last element of its import path. It is empty when the stack has no such frame.
+func PanicFrame(stack string) string {
+	lines := strings.Split(stack, "\n")
+	// the frames above the call to panic are the recovery code
+	start := 0
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") {
+			start = i + 1
+			break
+		}
+	}
+	for _, line := range lines[start:] {
+		if line == "" || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "goroutine ") ||
+			strings.HasPrefix(line, "created by ") || strings.HasPrefix(line, "runtime.") ||
+			strings.HasPrefix(line, "runtime/") || strings.HasPrefix(line, "panic(") {
+			continue
+		}
+		function := line
+		if i := strings.LastIndex(function, "("); i > 0 {
+			function = function[:i]
+		}
+		if i := strings.LastIndex(function, "/"); i >= 0 {
+			function = function[i+1:]
+		}
+		return function
+	}
+	return ""
+}
+
+// CaptureStatusMessage sends a warning message rather than an exception,
+// for responses that are worth tracking but are not errors, such as a 422 for invalid user input.
+func CaptureStatusMessage(hub *sentry.Hub, statusCode int, url string) *sentry.EventID {
+	var eventID *sentry.EventID
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelWarning)
+		eventID = hub.CaptureMessage("HTTP " + strconv.Itoa(statusCode) + ": " + url)
+	})
+	return eventID
+}
+
+// CaptureExceptionWithTimeout waits at most timeout for hub.CaptureException, which sends the event
+// before returning with a synchronous transport. Past the timeout the capture goes on in the background
+// and nil is returned. A timeout of 0 waits for the capture.
+// The capture uses the scope of the hub at the time of the call, so it can be called in hub.WithScope.
+func CaptureExceptionWithTimeout(hub *sentry.Hub, err error, timeout time.Duration) *sentry.EventID {
+	if timeout <= 0 {
+		return hub.CaptureException(err)
+	}
+	scopedHub := sentry.NewHub(hub.Client(), hub.Scope())
+	done := make(chan *sentry.EventID, 1)
+	go func() {
+		done <- scopedHub.CaptureException(err)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case eventID := <-done:
+		return eventID
+	case <-timer.C:
+		return nil
+	}
+}
+
+// FrameworkContextKey is the event context set by the Gin and Goa middlewares with IncludeFrameworkContext,
+// it holds the name and version of the framework and the route of the request.
+const FrameworkContextKey = "framework"
+
+// MethodLevel returns the level of a 500 error for the request method, falling back to defaultLevel.
+// An empty level means the level of the event is not changed.
+func MethodLevel(method string, levels map[string]sentry.Level, defaultLevel sentry.Level) sentry.Level {
+	if level, ok := levels[method]; ok {
+		return level
+	}
+	return defaultLevel
+}
+
 // group on the url and the beginning of the body.
 // The same url can have different errors: thus looking at the response body.
 // the longer the body is, the more likely it is to contain variable
@@ -46,7 +174,37 @@ func (e500 SentryError500) Fingerprint(_ []string) ([]string, error) {
 // The URL is normalized so that any path part with a number is replaced by a placeholder value
 func Fingerprint500(err error, fingerprint []string) ([]string, error) {
 	//nolint:errorlint
-	if ex, ok := err.(SentryError500); !ok {
+	if ex, ok := err.(SentryError500); ok {
+		return ex.Fingerprint(fingerprint)
+	}
+	return nil, nil
+}
+
+// SentryErrorTimeout is reported when a request runs past its deadline.
+type SentryErrorTimeout struct {
+	Url     string
+	Timeout time.Duration
+	Elapsed time.Duration
+}
+
+func (et SentryErrorTimeout) Error() string {
+	return "timeout after " + et.Elapsed.String() + " (limit " + et.Timeout.String() + ") " + et.Url
+}
+
+// Fingerprint groups timeouts on the url and the configured timeout.
+// The elapsed time varies on every request so it is not used.
+func (et SentryErrorTimeout) Fingerprint(_ []string) ([]string, error) {
+	u, err := url.Parse(et.Url)
+	if err != nil {
+		return nil, err
+	}
+	newPath := NormalizeUrlPathForSentry(u, "")
+	return []string{"timeout", newPath, et.Timeout.String()}, nil
+}
+
+func FingerprintTimeout(err error, fingerprint []string) ([]string, error) {
+	//nolint:errorlint
+	if ex, ok := err.(SentryErrorTimeout); ok {
 		return ex.Fingerprint(fingerprint)
 	}
 	return nil, nil
@@ -61,11 +219,67 @@ type Fingerprint func(err error, fingerprint []string) ([]string, error)
 type FingerprintOpts struct {
 	ErrHandler     func(err error)
 	Fingerprinters []Fingerprint
+	// IncludeHostnameInFingerprint groups events separately per host (pod).
+	// This helps find a bad instance but fragments grouping, so it is off by default.
+	IncludeHostnameInFingerprint bool
+	// BeforeBreadcrumb runs after the BeforeBreadcrumb of the original client, see WithBeforeBreadcrumb.
+	BeforeBreadcrumb func(*sentry.Breadcrumb, *sentry.BreadcrumbHint) *sentry.Breadcrumb
+	// Salt is prepended to the fingerprint segments as "salt:segment" to keep environments
+	// that share a Sentry project apart, e.g. the environment name.
+	Salt string
+	// CustomSDKName reports the events as sent by this module rather than by sentry-go,
+	// to tell them apart from the events of the application
+	CustomSDKName bool
+	// dynamic replaces the options on each event, see DynamicFingerprintOpts
+	dynamic func() FingerprintOpts
+}
+
+// DynamicFingerprintOpts returns options that call getter for every event and breadcrumb,
+// so that fingerprinting can change at runtime without recreating the hub, e.g. from a feature flag.
+// getter is called concurrently without locks, see NewAtomicFingerprintOpts.
+func DynamicFingerprintOpts(getter func() FingerprintOpts) FingerprintOpts {
+	return FingerprintOpts{dynamic: getter}
+}
+
+// current resolves dynamic options.
+func (o FingerprintOpts) current() FingerprintOpts {
+	if o.dynamic == nil {
+		return o
+	}
+	return o.dynamic()
+}
+
+// AtomicFingerprintOpts holds FingerprintOpts that can be replaced at runtime.
+type AtomicFingerprintOpts struct {
+	opts atomic.Pointer[FingerprintOpts]
+}
+
+// NewAtomicFingerprintOpts returns the holder and the dynamic options that read it, to pass to the middlewares.
+func NewAtomicFingerprintOpts(initial FingerprintOpts) (*AtomicFingerprintOpts, FingerprintOpts) {
+	a := &AtomicFingerprintOpts{}
+	a.Store(initial)
+	return a, DynamicFingerprintOpts(a.Load)
+}
+
+// Store replaces the options used by the next events.
+func (a *AtomicFingerprintOpts) Store(opts FingerprintOpts) {
+	a.opts.Store(&opts)
+}
+
+// Load returns the current options.
+func (a *AtomicFingerprintOpts) Load() FingerprintOpts {
+	return *a.opts.Load()
+}
+
+// WithBeforeBreadcrumb returns a copy of the options that filters breadcrumbs with fn.
+func (o FingerprintOpts) WithBeforeBreadcrumb(fn func(*sentry.Breadcrumb, *sentry.BreadcrumbHint) *sentry.Breadcrumb) FingerprintOpts {
+	o.BeforeBreadcrumb = fn
+	return o
 }
 
 var DefaultFingerprinter = FingerprintOpts{
 	ErrHandler:     DefaultFingerprintErrorHandler,
-	Fingerprinters: []Fingerprint{Fingerprint500},
+	Fingerprinters: []Fingerprint{Fingerprint500, FingerprintTimeout, FingerprintOutgoingRequest},
 }
 
 func HubCustomFingerprint(hub *sentry.Hub, fingerprintOpts FingerprintOpts) *sentry.Hub {
@@ -78,6 +292,22 @@ func HubCustomFingerprint(hub *sentry.Hub, fingerprintOpts FingerprintOpts) *sen
 	options.AttachStacktrace = false
 	// See: https://docs.sentry.io/platforms/go/usage/sdk-fingerprinting/
 	options.BeforeSend = func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		fingerprintOpts := fingerprintOpts.current()
+		//nolint:errorlint
+		if e500, ok := hint.OriginalException.(SentryError500); ok {
+			if event.Extra == nil {
+				event.Extra = map[string]interface{}{}
+			}
+			event.Extra["sentry_error"] = e500
+			if e500.Severity != "" {
+				event.Level = e500.Severity
+			}
+		}
+		if hint.RecoveredException != nil {
+			SetMechanism(event, MechanismPanic, false)
+		} else if hint.OriginalException != nil {
+			SetMechanism(event, MechanismHTTPMiddleware, true)
+		}
 		if oe := hint.OriginalException; oe != nil {
 			for _, fingerprinter := range fingerprintOpts.Fingerprinters {
 				fingerprint, err := fingerprinter(oe, event.Fingerprint)
@@ -88,22 +318,139 @@ func HubCustomFingerprint(hub *sentry.Hub, fingerprintOpts FingerprintOpts) *sen
 				}
 			}
 		}
+		if fingerprintOpts.IncludeHostnameInFingerprint {
+			if len(event.Fingerprint) == 0 {
+				event.Fingerprint = []string{"{{ default }}"}
+			}
+			event.Fingerprint = append(event.Fingerprint, fingerprintHostname())
+		}
+		if fingerprintOpts.Salt != "" {
+			event.Fingerprint = saltFingerprint(event.Fingerprint, fingerprintOpts.Salt)
+		}
+		if fingerprintOpts.CustomSDKName {
+			event.Sdk = sentry.SdkInfo{
+				Name:     SDKName,
+				Version:  Version,
+				Packages: []sentry.SdkPackage{{Name: SDKName, Version: Version}},
+			}
+		}
 		return event
 	}
+	// options.BeforeBreadcrumb of the original client is kept and composed with ours
+	if before := options.BeforeBreadcrumb; fingerprintOpts.BeforeBreadcrumb != nil || fingerprintOpts.dynamic != nil {
+		options.BeforeBreadcrumb = func(breadcrumb *sentry.Breadcrumb, hint *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			if before != nil {
+				if breadcrumb = before(breadcrumb, hint); breadcrumb == nil {
+					return nil
+				}
+			}
+			if after := fingerprintOpts.current().BeforeBreadcrumb; after != nil {
+				return after(breadcrumb, hint)
+			}
+			return breadcrumb
+		}
+	}
 	client, err := sentry.NewClient(options)
 	if err != nil {
-		return hub
+		return hub.Clone()
+	}
+	// the scope is cloned so that concurrent requests sharing a hub do not set data on each other's events
+	return sentry.NewHub(client, scope.Clone())
+}
+
+// saltFingerprint prefixes the segments with the salt. Variables such as {{ default }} are kept
+// and the salt is added as a segment of its own.
+func saltFingerprint(fingerprint []string, salt string) []string {
+	if len(fingerprint) == 0 {
+		return []string{"{{ default }}", salt}
+	}
+	salted := make([]string, 0, len(fingerprint)+1)
+	hasVariable := false
+	for _, segment := range fingerprint {
+		if strings.HasPrefix(segment, "{{") {
+			hasVariable = true
+			salted = append(salted, segment)
+			continue
+		}
+		salted = append(salted, salt+":"+segment)
+	}
+	if hasVariable {
+		salted = append(salted, salt)
+	}
+	return salted
+}
+
+// fingerprintHostname prefers HOSTNAME which Kubernetes sets to the pod name.
+func fingerprintHostname() string {
+	if hostname := os.Getenv("HOSTNAME"); hostname != "" {
+		return hostname
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown-host"
 	}
-	return sentry.NewHub(client, scope)
+	return hostname
 }
 
 type LogSentrySendFailures struct {
 	RT           http.RoundTripper
 	ErrorHandler func(context.Context, ErrSentryRoundTrip)
+	// Metrics are not collected when nil
+	*LogSentrySendFailuresMetrics
+	// RespectRetryAfter stops sending to Sentry until the Retry-After deadline of a 429 response passes.
+	// Requests in that time fail with an ErrSentryRoundTrip without being sent, the block is logged once.
+	// It requires a LogSentrySendFailures created with NewLogSentrySendFailures.
+	RespectRetryAfter bool
+	retryAfter        *retryAfter
+}
+
+// Flush flushes the wrapped transport when it is a FlushableTransport.
+func (lsf LogSentrySendFailures) Flush(timeout time.Duration) bool {
+	return MakeFlushable(lsf.RT).Flush(timeout)
 }
 
 func NewLogSentrySendFailures(rt http.RoundTripper) LogSentrySendFailures {
-	return LogSentrySendFailures{RT: rt, ErrorHandler: SlogErrHandler}
+	return LogSentrySendFailures{
+		RT:                           rt,
+		ErrorHandler:                 SlogErrHandler,
+		LogSentrySendFailuresMetrics: &LogSentrySendFailuresMetrics{},
+		retryAfter:                   newRetryAfter(),
+	}
+}
+
+// LogSentrySendFailuresMetrics counts deliveries to Sentry.
+type LogSentrySendFailuresMetrics struct {
+	// Sent counts 2xx responses
+	Sent atomic.Int64
+	// Failed counts responses >= 400 and requests without a response
+	Failed atomic.Int64
+}
+
+// Stats returns the number of events sent and failed so far, 0 when metrics are not collected.
+func (m *LogSentrySendFailuresMetrics) Stats() (sent, failed int64) {
+	if m == nil {
+		return 0, 0
+	}
+	return m.Sent.Load(), m.Failed.Load()
+}
+
+// RegisterWithExpvar publishes the counters as expvar variables named prefix + "sent" and "failed".
+// Like expvar.Publish it panics if a name is already registered.
+func (m *LogSentrySendFailuresMetrics) RegisterWithExpvar(prefix string) {
+	expvar.Publish(prefix+"sent", expvar.Func(func() any { return m.Sent.Load() }))
+	expvar.Publish(prefix+"failed", expvar.Func(func() any { return m.Failed.Load() }))
+}
+
+func (m *LogSentrySendFailuresMetrics) record(resp *http.Response) {
+	if m == nil {
+		return
+	}
+	switch {
+	case resp == nil || resp.StatusCode >= 400:
+		m.Failed.Add(1)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		m.Sent.Add(1)
+	}
 }
 
 func SlogErrHandler(ctx context.Context, err ErrSentryRoundTrip) {
@@ -112,7 +459,7 @@ func SlogErrHandler(ctx context.Context, err ErrSentryRoundTrip) {
 		attrs = append(attrs, slog.Int("status", err.Status))
 	}
 	if err.Exception != nil {
-		attrs = append(attrs, slog.String("exception", fmt.Sprintf("%v", err.Exception)))
+		attrs = append(attrs, slog.String("exception", err.ExceptionSummary()))
 	}
 	if err.Request != nil {
 		attrs = append(attrs, slog.String("request", string(err.Request)))
@@ -124,11 +471,7 @@ func SlogErrHandler(ctx context.Context, err ErrSentryRoundTrip) {
 }
 
 func RedactDSN(body []byte) []byte {
-	re, err := regexp.Compile(`[^ '"]+sentry\.io/[^ '"]+`)
-	if err != nil {
-		panic(err)
-	}
-	return re.ReplaceAll(body, []byte("REDACTED"))
+	return RedactSensitiveData(body, []RedactPattern{DSNPattern})
 }
 
 type ErrSentryRoundTrip struct {
@@ -140,13 +483,22 @@ type ErrSentryRoundTrip struct {
 	Response  []byte
 }
 
+// ExceptionSummary returns "<type>: <value>" of the last exception, the one that was captured, or "<none>".
+func (esrt ErrSentryRoundTrip) ExceptionSummary() string {
+	if len(esrt.Exception) == 0 {
+		return "<none>"
+	}
+	last := esrt.Exception[len(esrt.Exception)-1]
+	return last.Type + ": " + last.Value
+}
+
 func (esrt ErrSentryRoundTrip) Error() string {
 	var attrs string
 	if esrt.Status != 0 {
 		attrs = attrs + fmt.Sprintf(" status=%d", esrt.Status)
 	}
 	if esrt.Exception != nil {
-		attrs = attrs + fmt.Sprintf(" exception=%v", esrt.Request)
+		attrs = attrs + fmt.Sprintf(" exception=%v", esrt.Exception)
 	}
 	if esrt.Request != nil {
 		attrs = attrs + " request=" + string(esrt.Request)
@@ -154,12 +506,41 @@ func (esrt ErrSentryRoundTrip) Error() string {
 	if esrt.Response != nil {
 		attrs = attrs + " response=" + string(esrt.Response)
 	}
+	if esrt.Err == nil {
+		return esrt.Msg + attrs
+	}
 	return esrt.Msg + ": " + esrt.Err.Error() + " " + attrs
 }
 
 func (lsf LogSentrySendFailures) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !lsf.RespectRetryAfter || lsf.retryAfter == nil {
+		return lsf.roundTrip(req)
+	}
+	if until, blocked := lsf.retryAfter.blocked(); blocked {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		lsf.LogSentrySendFailuresMetrics.record(nil)
+		return nil, ErrSentryRoundTrip{
+			Msg:    "Sentry event not sent: rate limited until " + until.Format(time.RFC3339),
+			Status: http.StatusTooManyRequests,
+		}
+	}
+	resp, err := lsf.roundTrip(req)
+	if until, isNew := lsf.retryAfter.observe(resp); isNew {
+		lsf.ErrorHandler(req.Context(), ErrSentryRoundTrip{
+			Msg:    "Sentry rate limit: not sending events until " + until.Format(time.RFC3339),
+			Status: http.StatusTooManyRequests,
+		})
+	}
+	return resp, err
+}
+
+func (lsf LogSentrySendFailures) roundTrip(req *http.Request) (*http.Response, error) {
 	if req.Body == nil {
-		return lsf.RT.RoundTrip(req)
+		resp, err := lsf.RT.RoundTrip(req)
+		lsf.LogSentrySendFailuresMetrics.record(resp)
+		return resp, err
 	}
 	ctx := req.Context()
 
@@ -170,13 +551,16 @@ func (lsf LogSentrySendFailures) RoundTrip(req *http.Request) (*http.Response, e
 	req.Body = io.NopCloser(tee)
 	resp, err := lsf.RT.RoundTrip(req)
 	req.Body = io.NopCloser(&buf)
+	lsf.LogSentrySendFailuresMetrics.record(resp)
 
 	var statusCode int
 	if resp != nil {
 		statusCode = resp.StatusCode
 	}
 	if statusCode >= 400 || resp == nil {
-		body, err := io.ReadAll(tee)
+		// drain what the transport did not read, the whole body is then in buf
+		_, err := io.ReadAll(tee)
+		body := buf.Bytes()
 		if err != nil {
 			lsf.ErrorHandler(ctx, ErrSentryRoundTrip{
 				Msg:    "Sentry event send failure: error recovering request body",
@@ -184,13 +568,13 @@ func (lsf LogSentrySendFailures) RoundTrip(req *http.Request) (*http.Response, e
 				Status: statusCode,
 			})
 		} else {
-			event := sentry.Event{}
-			if err := json.Unmarshal(body, &event); err != nil {
+			event, err := eventFromRequestBody(body)
+			if err != nil {
 				lsf.ErrorHandler(ctx, ErrSentryRoundTrip{
 					Msg:     "Sentry event send failure: error recovering request json",
 					Err:     err,
 					Status:  statusCode,
-					Request: RedactDSN(body),
+					Request: RedactSensitiveData(body, DefaultRedactPatterns),
 				})
 			}
 			var rspBody []byte
@@ -204,9 +588,10 @@ func (lsf LogSentrySendFailures) RoundTrip(req *http.Request) (*http.Response, e
 				rspBody, err = io.ReadAll(teeRsp)
 				if err != nil {
 					lsf.ErrorHandler(ctx, ErrSentryRoundTrip{
-						Msg:    "Sentry event send failure: error reading response body",
-						Err:    err,
-						Status: statusCode,
+						Msg:       "Sentry event send failure: error reading response body",
+						Err:       err,
+						Status:    statusCode,
+						Exception: event.Exception,
 					})
 				}
 			}
@@ -215,28 +600,97 @@ func (lsf LogSentrySendFailures) RoundTrip(req *http.Request) (*http.Response, e
 				Msg:       "Sentry event",
 				Status:    statusCode,
 				Exception: event.Exception,
-				Response:  RedactDSN(rspBody),
+				Response:  RedactSensitiveData(rspBody, DefaultRedactPatterns),
 			})
 		}
 	}
 	return resp, err
 }
 
+// eventFromRequestBody decodes the event sent to Sentry.
+// Newer transports send an envelope: a header line followed by items, one of which is the event.
+func eventFromRequestBody(body []byte) (sentry.Event, error) {
+	event := sentry.Event{}
+	payload, err := eventPayload(body)
+	if err != nil {
+		return event, err
+	}
+	err = json.Unmarshal(payload, &event)
+	return event, err
+}
+
+// NormalizeOpts configures NormalizeUrlPathWithOpts.
+type NormalizeOpts struct {
+	// Placeholder replaces path parts that contain a number, defaults to "-omitted-"
+	Placeholder string
+	// VersionSegments are path parts kept as they are, e.g. "v1"
+	VersionSegments []string
+	// VersionRegex matches path parts kept as they are, nil disables it
+	VersionRegex *regexp.Regexp
+	// DateVersionRegex matches date versions replaced with "{date}", nil disables it
+	DateVersionRegex *regexp.Regexp
+	// CollapseAdjacentDuplicates keeps one of adjacent path parts that are equal ignoring case, e.g. /api/api/v1 is /api/v1.
+	// Empty parts of double slashes are dropped first.
+	CollapseAdjacentDuplicates bool
+	// DecodeURLEncoding unescapes the path parts before they are replaced, a part with an encoded separator
+	// such as orders%2F42 is split into orders and 42.
+	// It is for paths escaped twice or built by hand, url.URL.Path is already unescaped once.
+	DecodeURLEncoding bool
+}
+
+var DefaultNormalizeOpts = NormalizeOpts{
+	VersionSegments:  []string{"v1", "v2"},
+	VersionRegex:     regexp.MustCompile(`^v\d+$`),
+	DateVersionRegex: dateVersionRegex,
+}
+
+var dateVersionRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Regular expression to match numeric parts of the path
+var numericRegex = regexp.MustCompile("[0-9]+")
+
 // NormalizeUrlPathForSentry takes a url path string and replaces any path part that contains a number with a standard placeholder value.
 // This allows for better error grouping at Sentry for urls that may contain dynamic values (UUID for example) but are basically the same URL in general
 func NormalizeUrlPathForSentry(url *url.URL, placeholder string) string {
+	opts := DefaultNormalizeOpts
+	opts.Placeholder = placeholder
+	return NormalizeUrlPathWithOpts(url, opts)
+}
+
+// NormalizeUrlPathWithOpts is NormalizeUrlPathForSentry keeping API versions such as /v3/ or /2024-01-01/
+// so that they are grouped separately.
+func NormalizeUrlPathWithOpts(url *url.URL, opts NormalizeOpts) string {
+	// Fast path: without a number no part is replaced, unless a custom DateVersionRegex matches it
+	if !opts.CollapseAdjacentDuplicates && !opts.DecodeURLEncoding && (opts.DateVersionRegex == nil || opts.DateVersionRegex == dateVersionRegex) &&
+		!strings.ContainsAny(url.Path, "0123456789") {
+		return strings.TrimSuffix(url.Path, "/")
+	}
+	return normalizeUrlPathParts(url.Path, opts)
+}
+
+// normalizeUrlPathParts replaces the parts of the path one by one.
+func normalizeUrlPathParts(path string, opts NormalizeOpts) string {
+	placeholder := opts.Placeholder
 	if placeholder == "" {
 		placeholder = "-omitted-"
 	}
-	pathParts := strings.Split(url.Path, "/")
-
-	// Regular expression to match numeric parts of the path
-	numericRegex := regexp.MustCompile("[0-9]+")
+	pathParts := strings.Split(path, "/")
+	if opts.DecodeURLEncoding {
+		pathParts = decodePathParts(pathParts)
+	}
+	if opts.CollapseAdjacentDuplicates {
+		pathParts = collapseAdjacentDuplicates(pathParts)
+	}
 
 	// Iterate over each part of the path
 	for i, part := range pathParts {
+		switch {
+		case slices.Contains(opts.VersionSegments, part):
+		case opts.VersionRegex != nil && opts.VersionRegex.MatchString(part):
+		case opts.DateVersionRegex != nil && opts.DateVersionRegex.MatchString(part):
+			pathParts[i] = "{date}"
 		// Check if the part contains a number
-		if part != "v1" && part != "v2" && numericRegex.MatchString(part) {
+		case numericRegex.MatchString(part):
 			// Replace the numeric part with "placeholder"
 			pathParts[i] = placeholder
 		}
@@ -248,27 +702,76 @@ func NormalizeUrlPathForSentry(url *url.URL, placeholder string) string {
 	return newPath
 }
 
+// decodePathParts unescapes the parts, a part that is not valid escaping is kept as it is.
+// The parts of an encoded separator are decoded again, in case they were escaped twice.
+func decodePathParts(pathParts []string) []string {
+	decoded := make([]string, 0, len(pathParts))
+	for _, part := range pathParts {
+		if unescaped, err := url.PathUnescape(part); err == nil && unescaped != part {
+			decoded = append(decoded, decodePathParts(strings.Split(unescaped, "/"))...)
+			continue
+		}
+		decoded = append(decoded, part)
+	}
+	return decoded
+}
+
+// collapseAdjacentDuplicates keeps the leading empty part of an absolute path.
+func collapseAdjacentDuplicates(pathParts []string) []string {
+	collapsed := pathParts[:1]
+	for _, part := range pathParts[1:] {
+		if part == "" || strings.EqualFold(part, collapsed[len(collapsed)-1]) {
+			continue
+		}
+		collapsed = append(collapsed, part)
+	}
+	return collapsed
+}
+
 type UnwrapAndFilterErrorTypeConfig struct {
+	// FilterErrorTypes are filtered in addition to the registered types, see RegisterFilterErrorType
 	FilterErrorTypes []string
+	// MultiUnwrap traverses every branch of errors with an `Unwrap() []error` method (errors.Join, multi-cause errors).
+	// When several branches have a non-filtered type, the longest qualified type name is used.
+	MultiUnwrap bool
+	// MaxDepth stops unwrapping after that many Unwrap calls and uses the type found at that depth, 0 means no limit.
+	// 10 is enough for most chains and bounds the cost of errors wrapped in a retry loop.
+	MaxDepth int
+	// ExceptionValueRedactor is applied to the value of every exception of the event,
+	// see IPAddressRedactor and InternalHostRedactor
+	ExceptionValueRedactor func(string) string
 }
 
 // Golang error types tend to be generic wrappers
 // Unwrap known generic error types until we find an unrecognized error type
 // That error type is assumed to be useful
 // Otherwise just strip the "*errors." or "errors." prefix which adds noise
+// The registered types, see RegisterFilterErrorType, are filtered with those of conf.FilterErrorTypes.
 func SentryBeforeSendUnwrapAndFilterErrorType(conf UnwrapAndFilterErrorTypeConfig) func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
-	if len(conf.FilterErrorTypes) == 0 {
-		conf.FilterErrorTypes = defaultFilterErrorTypes
-	}
 	return conf.sentryBeforeSendUnwrapAndFilterErrorType
 }
 
 func (conf UnwrapAndFilterErrorTypeConfig) sentryBeforeSendUnwrapAndFilterErrorType(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	if conf.ExceptionValueRedactor != nil {
+		for i := range event.Exception {
+			event.Exception[i].Value = conf.ExceptionValueRedactor(event.Exception[i].Value)
+		}
+	}
 	oe := hint.OriginalException
 	if oe == nil {
 		return event
 	}
-	errStr := unwrapToSpecificError(oe, conf.FilterErrorTypes)
+	filter := FilterErrorTypes()
+	if len(conf.FilterErrorTypes) > 0 {
+		filter = append(filter, conf.FilterErrorTypes...)
+	}
+	var errStr *string
+	if conf.MultiUnwrap {
+		errStr = mostSpecificErrorType(collectSpecificErrorTypes(oe, filter, 0, conf.MaxDepth, nil))
+	}
+	if errStr == nil {
+		errStr = unwrapToSpecificError(oe, filter, conf.MaxDepth)
+	}
 	exLastIndex := len(event.Exception) - 1
 	if errStr != nil && *errStr != event.Exception[exLastIndex].Type {
 		event.Exception[exLastIndex].Type = *errStr
@@ -278,11 +781,85 @@ func (conf UnwrapAndFilterErrorTypeConfig) sentryBeforeSendUnwrapAndFilterErrorT
 
 var defaultFilterErrorTypes = []string{"errors.", "fmt.wrapError"}
 
-func unwrapToSpecificError(err error, filterErrorTypes []string) *string {
+var (
+	filterErrorTypesMu sync.RWMutex
+	// filterErrorTypes are the default types and those added with RegisterFilterErrorType
+	filterErrorTypes = slices.Clone(defaultFilterErrorTypes)
+)
+
+// RegisterFilterErrorType adds the type prefix of a generic error wrapper, e.g. of a framework,
+// to the types filtered by every SentryBeforeSendUnwrapAndFilterErrorType.
+// It is meant to be called from an init function, the gin and goa packages register their types when imported.
+func RegisterFilterErrorType(prefix string) {
+	filterErrorTypesMu.Lock()
+	defer filterErrorTypesMu.Unlock()
+	if !slices.Contains(filterErrorTypes, prefix) {
+		filterErrorTypes = append(filterErrorTypes, prefix)
+	}
+}
+
+// FilterErrorTypes returns a copy of the registered type prefixes of generic error wrappers.
+func FilterErrorTypes() []string {
+	filterErrorTypesMu.RLock()
+	defer filterErrorTypesMu.RUnlock()
+	return slices.Clone(filterErrorTypes)
+}
+
+// filteredErrorType reports whether the type of err is a generic wrapper listed in filterErrorTypes.
+func filteredErrorType(err error, filterErrorTypes []string) bool {
+	typStr := reflect.TypeOf(err).String()
+	for _, prefix := range filterErrorTypes {
+		if strings.HasPrefix(typStr, prefix) || strings.HasPrefix(typStr, "*"+prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectSpecificErrorTypes walks all branches of the error tree and returns the first non-filtered type of each branch.
+// Errors more than maxDepth Unwrap calls away from the root are not walked when maxDepth is not 0.
+func collectSpecificErrorTypes(err error, filterErrorTypes []string, depth, maxDepth int, found []string) []string {
+	if err == nil {
+		return found
+	}
+	if !filteredErrorType(err, filterErrorTypes) {
+		return append(found, reflect.TypeOf(err).String())
+	}
+	if maxDepth > 0 && depth >= maxDepth {
+		return found
+	}
+	switch wrapped := err.(type) { //nolint:errorlint
+	case interface{ Unwrap() []error }:
+		for _, branch := range wrapped.Unwrap() {
+			found = collectSpecificErrorTypes(branch, filterErrorTypes, depth+1, maxDepth, found)
+		}
+	case interface{ Unwrap() error }:
+		found = collectSpecificErrorTypes(wrapped.Unwrap(), filterErrorTypes, depth+1, maxDepth, found)
+	}
+	return found
+}
+
+// mostSpecificErrorType picks the longest qualified type name, which tends to be the most informative.
+// The first one wins a tie.
+func mostSpecificErrorType(types []string) *string {
+	if len(types) == 0 {
+		return nil
+	}
+	best := types[0]
+	for _, typ := range types[1:] {
+		if len(typ) > len(best) {
+			best = typ
+		}
+	}
+	return &best
+}
+
+// unwrapToSpecificError calls Unwrap at most maxDepth times when it is not 0.
+func unwrapToSpecificError(err error, filterErrorTypes []string, maxDepth int) *string {
 	var typStr string
 	var firstTypStr string
 	var underlying error
-	for {
+	for depth := 0; ; depth++ {
 		typ := reflect.TypeOf(err)
 		if typ == nil {
 			break
@@ -308,7 +885,7 @@ func unwrapToSpecificError(err error, filterErrorTypes []string) *string {
 			firstTypStr = typStr
 		}
 		// Look for a non-filtered error type
-		if found {
+		if found && (maxDepth == 0 || depth < maxDepth) {
 			if underlying = errors.Unwrap(err); underlying != nil {
 				err = underlying
 				continue
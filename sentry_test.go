@@ -1,12 +1,24 @@
 package sentry
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
 )
 
 func TestRedactDSN(t *testing.T) {
@@ -59,20 +71,583 @@ func (te testErr) Error() string {
 
 func TestUnwrapToSpecificError(t *testing.T) {
 	d := defaultFilterErrorTypes
-	if errStr := unwrapToSpecificError(errors.New("test"), d); *errStr != "errorString" {
+	if errStr := unwrapToSpecificError(errors.New("test"), d, 0); *errStr != "errorString" {
 		t.Errorf("unexpected %s", *errStr)
 	}
-	if errStr := unwrapToSpecificError(fmt.Errorf("test"), d); *errStr != "errorString" {
+	if errStr := unwrapToSpecificError(fmt.Errorf("test"), d, 0); *errStr != "errorString" {
 		t.Errorf("unexpected %s", *errStr)
 	}
-	if errStr := unwrapToSpecificError(http.ErrAbortHandler, d); *errStr != "errorString" {
+	if errStr := unwrapToSpecificError(http.ErrAbortHandler, d, 0); *errStr != "errorString" {
 		t.Errorf("unexpected %s", *errStr)
 	}
-	if errStr := unwrapToSpecificError(testErr{}, d); *errStr != "sentry.testErr" {
+	if errStr := unwrapToSpecificError(testErr{}, d, 0); *errStr != "sentry.testErr" {
 		t.Errorf("unexpected %s", *errStr)
 	}
 	wrapped := fmt.Errorf("%w", testErr{})
-	if errStr := unwrapToSpecificError(wrapped, d); *errStr != "sentry.testErr" {
+	if errStr := unwrapToSpecificError(wrapped, d, 0); *errStr != "sentry.testErr" {
 		t.Errorf("unexpected %s", *errStr)
 	}
 }
+
+// wrapCountingErr counts the Unwrap calls of the whole chain.
+type wrapCountingErr struct {
+	err   error
+	calls *int
+}
+
+func (cwe *wrapCountingErr) Error() string { return "retry: " + cwe.err.Error() }
+func (cwe *wrapCountingErr) Unwrap() error {
+	*cwe.calls++
+	return cwe.err
+}
+
+func deepErrorChain(depth int, calls *int) error {
+	var err error = testErr{}
+	for i := 0; i < depth; i++ {
+		err = &wrapCountingErr{err: err, calls: calls}
+	}
+	return err
+}
+
+func TestUnwrapToSpecificErrorMaxDepth(t *testing.T) {
+	filter := append([]string{"sentry.wrap"}, defaultFilterErrorTypes...)
+	var calls int
+	err := deepErrorChain(100, &calls)
+	if errStr := unwrapToSpecificError(err, filter, 5); *errStr != "CountingErr" || calls != 5 {
+		t.Errorf("expected to stop after 5 Unwrap calls, got %s after %d", *errStr, calls)
+	}
+	calls = 0
+	if errStr := unwrapToSpecificError(err, filter, 0); *errStr != "sentry.testErr" || calls != 100 {
+		t.Errorf("expected the whole chain to be unwrapped, got %s after %d", *errStr, calls)
+	}
+
+	beforeSend := SentryBeforeSendUnwrapAndFilterErrorType(UnwrapAndFilterErrorTypeConfig{
+		FilterErrorTypes: filter, MaxDepth: 5,
+	})
+	calls = 0
+	event := &sentry.Event{Exception: []sentry.Exception{{Type: "original"}}}
+	event = beforeSend(event, &sentry.EventHint{OriginalException: err})
+	if typ := event.Exception[0].Type; typ != "CountingErr" || calls != 5 {
+		t.Errorf("expected the MaxDepth of the config to be used, got %s after %d", typ, calls)
+	}
+}
+
+type registeredWrapErr struct{ err error }
+
+func (rwe registeredWrapErr) Error() string { return "framework: " + rwe.err.Error() }
+func (rwe registeredWrapErr) Unwrap() error { return rwe.err }
+
+func TestRegisterFilterErrorType(t *testing.T) {
+	saved := FilterErrorTypes()
+	t.Cleanup(func() {
+		filterErrorTypesMu.Lock()
+		defer filterErrorTypesMu.Unlock()
+		filterErrorTypes = saved
+	})
+	beforeSend := SentryBeforeSendUnwrapAndFilterErrorType(UnwrapAndFilterErrorTypeConfig{})
+	errorType := func(err error) string {
+		event := &sentry.Event{Exception: []sentry.Exception{{Type: "original"}}}
+		return beforeSend(event, &sentry.EventHint{OriginalException: err}).Exception[0].Type
+	}
+	err := fmt.Errorf("job: %w", registeredWrapErr{testErr{}})
+	if typ := errorType(err); typ != "sentry.registeredWrapErr" {
+		t.Errorf("unexpected type before registering %s", typ)
+	}
+	RegisterFilterErrorType("sentry.registeredWrapErr")
+	RegisterFilterErrorType("sentry.registeredWrapErr")
+	if typ := errorType(err); typ != "sentry.testErr" {
+		t.Errorf("expected the registered type to be filtered by an existing BeforeSend, got %s", typ)
+	}
+	registered := FilterErrorTypes()
+	if !slices.Contains(registered, "errors.") || slices.Index(registered, "sentry.registeredWrapErr") != len(registered)-1 {
+		t.Errorf("expected the prefix to be registered once after the defaults %v", registered)
+	}
+
+	beforeSend = SentryBeforeSendUnwrapAndFilterErrorType(UnwrapAndFilterErrorTypeConfig{FilterErrorTypes: []string{"sentry.wrap"}})
+	if typ := errorType(err); typ != "sentry.testErr" {
+		t.Errorf("expected the types of the config to be merged with the registered ones, got %s", typ)
+	}
+	beforeSend = SentryBeforeSendUnwrapAndFilterErrorType(UnwrapAndFilterErrorTypeConfig{FilterErrorTypes: []string{}})
+	if typ := errorType(err); typ != "sentry.testErr" {
+		t.Errorf("expected an empty FilterErrorTypes to keep the registered types, got %s", typ)
+	}
+}
+
+func BenchmarkUnwrapToSpecificError(b *testing.B) {
+	filter := append([]string{"sentry.wrap"}, defaultFilterErrorTypes...)
+	var calls int
+	err := deepErrorChain(100, &calls)
+	for _, maxDepth := range []int{0, 10} {
+		b.Run(fmt.Sprintf("MaxDepth=%d", maxDepth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				unwrapToSpecificError(err, filter, maxDepth)
+			}
+		})
+	}
+}
+
+// Fingerprint500 used to test !ok, so a SentryError500 got no fingerprint
+// and any other error was fingerprinted as an empty SentryError500.
+func TestFingerprint500(t *testing.T) {
+	fp, err := Fingerprint500(SentryError500{Url: "https://example.com/v1/users/123", Body: "boom"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fp, " ") != "/v1/users/-omitted- boom" {
+		t.Errorf("unexpected %v", fp)
+	}
+	if fp, err := Fingerprint500(errors.New("other"), nil); fp != nil || err != nil {
+		t.Errorf("expected other errors to be ignored %v %v", fp, err)
+	}
+}
+
+func TestFingerprintTimeout(t *testing.T) {
+	err := SentryErrorTimeout{Url: "https://example.com/v1/users/123", Timeout: 5 * time.Second, Elapsed: 5100 * time.Millisecond}
+	fp, fpErr := FingerprintTimeout(err, nil)
+	if fpErr != nil {
+		t.Fatal(fpErr)
+	}
+	if strings.Join(fp, " ") != "timeout /v1/users/-omitted- 5s" {
+		t.Errorf("unexpected %v", fp)
+	}
+	if fp, _ := Fingerprint500(err, nil); fp != nil {
+		t.Errorf("500 fingerprinter should ignore timeouts %v", fp)
+	}
+}
+
+func TestHubCustomFingerprintHostname(t *testing.T) {
+	t.Setenv("HOSTNAME", "api-7d9f-xk2p")
+	hubOrig, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultFingerprinter
+	opts.IncludeHostnameInFingerprint = true
+	hub := HubCustomFingerprint(hubOrig, opts)
+	hub.CaptureException(SentryError500{Url: "https://example.com/users/1", Body: "boom"})
+	hub.CaptureException(errors.New("not fingerprinted"))
+
+	if len(transport.Events()) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+	}
+	if fp := transport.Events()[0].Fingerprint; strings.Join(fp, " ") != "/users/-omitted- boom api-7d9f-xk2p" {
+		t.Errorf("unexpected %v", fp)
+	}
+	if fp := transport.Events()[1].Fingerprint; strings.Join(fp, " ") != "{{ default }} api-7d9f-xk2p" {
+		t.Errorf("unexpected %v", fp)
+	}
+}
+
+func TestHubCustomFingerprintSeverity(t *testing.T) {
+	hubOrig, transport := sentrytest.NewRecordingHub(t)
+	hub := HubCustomFingerprint(hubOrig, DefaultFingerprinter)
+	hub.CaptureException(SentryError500{Url: "https://example.com/payments", Body: "declined", Severity: sentry.LevelFatal})
+	hub.CaptureException(SentryError500{Url: "https://example.com/recommendations", Body: "timeout", Severity: sentry.LevelWarning})
+	hub.CaptureException(SentryError500{Url: "https://example.com/users", Body: "boom"})
+
+	if len(transport.Events()) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(transport.Events()))
+	}
+	for i, want := range []sentry.Level{sentry.LevelFatal, sentry.LevelWarning, sentry.LevelError} {
+		if transport.Events()[i].Level != want {
+			t.Errorf("event %d: got level %s, want %s", i, transport.Events()[i].Level, want)
+		}
+	}
+}
+
+func TestHubCustomFingerprintCustomSDKName(t *testing.T) {
+	hubOrig, transport := sentrytest.NewRecordingHub(t)
+	opts := DefaultFingerprinter
+	opts.CustomSDKName = true
+	HubCustomFingerprint(hubOrig, opts).CaptureException(errors.New("from the middleware"))
+	HubCustomFingerprint(hubOrig, DefaultFingerprinter).CaptureException(errors.New("from the application"))
+
+	if len(transport.Events()) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+	}
+	if sdk := transport.Events()[0].Sdk; sdk.Name != SDKName || sdk.Version != Version || len(sdk.Packages) != 1 {
+		t.Errorf("unexpected sdk %v", sdk)
+	}
+	if sdk := transport.Events()[1].Sdk; sdk.Name == SDKName {
+		t.Errorf("expected the sentry-go sdk %v", sdk)
+	}
+}
+
+func TestHubCustomFingerprintSalt(t *testing.T) {
+	fingerprints := func(salt string) []string {
+		hubOrig, transport := sentrytest.NewRecordingHub(t)
+		opts := DefaultFingerprinter
+		opts.Salt = salt
+		hub := HubCustomFingerprint(hubOrig, opts)
+		hub.CaptureException(SentryError500{Url: "https://example.com/users/1", Body: "boom"})
+		hub.CaptureException(errors.New("not fingerprinted"))
+		if len(transport.Events()) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+		}
+		return []string{
+			strings.Join(transport.Events()[0].Fingerprint, " "),
+			strings.Join(transport.Events()[1].Fingerprint, " "),
+		}
+	}
+	if fp := fingerprints(""); fp[0] != "/users/-omitted- boom" || fp[1] != "" {
+		t.Errorf("unexpected %q", fp)
+	}
+	if fp := fingerprints("staging"); fp[0] != "staging:/users/-omitted- staging:boom" || fp[1] != "{{ default }} staging" {
+		t.Errorf("unexpected %q", fp)
+	}
+}
+
+func TestHubCustomFingerprintBeforeBreadcrumb(t *testing.T) {
+	transport := &sentrytest.EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "https://key@o1.ingest.sentry.io/1",
+		Transport: transport,
+		BeforeBreadcrumb: func(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			if breadcrumb.Category == "sql" {
+				return nil
+			}
+			return breadcrumb
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultFingerprinter.WithBeforeBreadcrumb(func(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+		if breadcrumb.Category == "http" {
+			return nil
+		}
+		breadcrumb.Message = strings.ToUpper(breadcrumb.Message)
+		return breadcrumb
+	})
+	hub := HubCustomFingerprint(sentry.NewHub(client, sentry.NewScope()), opts)
+	for _, category := range []string{"sql", "http", "ui"} {
+		hub.AddBreadcrumb(&sentry.Breadcrumb{Category: category, Message: category}, nil)
+	}
+	hub.CaptureException(SentryError500{Url: "/"})
+
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	breadcrumbs := transport.Events()[0].Breadcrumbs
+	if len(breadcrumbs) != 1 || breadcrumbs[0].Message != "UI" {
+		t.Errorf("unexpected %v", breadcrumbs)
+	}
+}
+
+// envelope from https://develop.sentry.dev/sdk/envelopes/ with an exception added to the event
+const sampleEnvelope = `{"event_id":"9ec79c33ec9942ab8353589fcb2e04dc","dsn":"https://e12d836b15bb49d7bbf99e64295d995b:@sentry.io/42"}
+{"type":"attachment","length":10,"content_type":"text/plain","filename":"hello.txt"}
+\xef\xbb\xbfHello
+
+{"type":"event","length":112,"content_type":"application/json","filename":"application.log"}
+{"message":"hello world","level":"error","exception":[{"type":"ValueError","value":"bad input","module":"app"}]}
+`
+
+func TestRoundTripEnvelope(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, "rate limited")
+	}))
+	defer ts.Close()
+
+	var logged []ErrSentryRoundTrip
+	lsf := NewLogSentrySendFailures(http.DefaultTransport)
+	lsf.ErrorHandler = func(_ context.Context, err ErrSentryRoundTrip) {
+		logged = append(logged, err)
+	}
+	body := strings.ReplaceAll(sampleEnvelope, `\xef\xbb\xbf`, "\xef\xbb\xbf")
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/42/envelope/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := lsf.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if len(logged) != 1 {
+		t.Fatalf("expected only the event to be logged %v", logged)
+	}
+	if ex := logged[0].Exception; len(ex) != 1 || ex[0].Type != "ValueError" {
+		t.Errorf("unexpected exception %v", ex)
+	}
+	if string(logged[0].Response) != "rate limited" {
+		t.Errorf("unexpected response %s", logged[0].Response)
+	}
+}
+
+type multiErr struct{ errs []error }
+
+func (me multiErr) Error() string   { return "multi" }
+func (me multiErr) Unwrap() []error { return me.errs }
+
+type validationFailedErr struct{}
+
+func (vfe validationFailedErr) Error() string { return "validation failed" }
+
+func TestSentryBeforeSendMultiUnwrap(t *testing.T) {
+	conf := UnwrapAndFilterErrorTypeConfig{MultiUnwrap: true}
+	beforeSend := SentryBeforeSendUnwrapAndFilterErrorType(conf)
+	typeOf := func(err error) string {
+		event := &sentry.Event{Exception: []sentry.Exception{{Type: "original"}}}
+		event = beforeSend(event, &sentry.EventHint{OriginalException: err})
+		return event.Exception[0].Type
+	}
+
+	joined := errors.Join(errors.New("a"), fmt.Errorf("b: %w", testErr{}))
+	if typ := typeOf(joined); typ != "sentry.testErr" {
+		t.Errorf("unexpected %s", typ)
+	}
+	custom := fmt.Errorf("wrapped: %w", multiErr{errs: []error{testErr{}, validationFailedErr{}}})
+	if typ := typeOf(custom); typ != "sentry.multiErr" {
+		t.Errorf("unfiltered multi error types are kept %s", typ)
+	}
+	mixed := errors.Join(errors.New("a"), testErr{}, fmt.Errorf("c: %w", validationFailedErr{}))
+	if typ := typeOf(mixed); typ != "sentry.validationFailedErr" {
+		t.Errorf("expected the longest type name %s", typ)
+	}
+	if typ := typeOf(errors.Join(errors.New("a"), errors.New("b"))); typ != "joinError" {
+		t.Errorf("expected fallback to the filtered type %s", typ)
+	}
+}
+
+func TestLogSentrySendFailuresMetrics(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusOK)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer ts.Close()
+
+	lsf := NewLogSentrySendFailures(http.DefaultTransport)
+	lsf.ErrorHandler = func(context.Context, ErrSentryRoundTrip) {}
+	send := func() {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"message":"m"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := lsf.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	send()
+	send()
+	status.Store(http.StatusInternalServerError)
+	send()
+
+	if sent, failed := lsf.Stats(); sent != 2 || failed != 1 {
+		t.Errorf("unexpected sent=%d failed=%d", sent, failed)
+	}
+}
+
+func TestLogSentrySendFailuresMetricsNil(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	lsf := LogSentrySendFailures{RT: http.DefaultTransport, ErrorHandler: func(context.Context, ErrSentryRoundTrip) {}}
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"message":"m"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := lsf.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if sent, failed := lsf.Stats(); sent != 0 || failed != 0 {
+		t.Errorf("unexpected sent=%d failed=%d without metrics", sent, failed)
+	}
+}
+
+func TestErrSentryRoundTripExceptionSummary(t *testing.T) {
+	tests := []struct {
+		exception []sentry.Exception
+		want      string
+	}{
+		{nil, "<none>"},
+		{[]sentry.Exception{}, "<none>"},
+		{[]sentry.Exception{{Type: "*errors.errorString", Value: "boom"}}, "*errors.errorString: boom"},
+		{[]sentry.Exception{{Type: "cause", Value: "inner"}, {Type: "wrapper", Value: "outer"}}, "wrapper: outer"},
+		{[]sentry.Exception{{Value: "no type"}}, ": no type"},
+	}
+	for _, tt := range tests {
+		if got := (ErrSentryRoundTrip{Exception: tt.exception}).ExceptionSummary(); got != tt.want {
+			t.Errorf("%v: got %q want %q", tt.exception, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeUrlPathWithOpts(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/users/42", "/v1/users/-omitted-"},
+		{"/api/v3/users/42/", "/api/v3/users/-omitted-"},
+		{"/api/v12/orders", "/api/v12/orders"},
+		{"/api/2024-01-01/users/42", "/api/{date}/users/-omitted-"},
+		{"/apis/v1alpha1/pods", "/apis/-omitted-/pods"},
+		{"/v2beta/items", "/-omitted-/items"},
+		{"/2024-01-01T10:00/users", "/-omitted-/users"},
+		{"/users/alice", "/users/alice"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeUrlPathWithOpts(&url.URL{Path: tt.path}, DefaultNormalizeOpts); got != tt.want {
+			t.Errorf("%s: got %q want %q", tt.path, got, tt.want)
+		}
+	}
+
+	opts := NormalizeOpts{VersionSegments: []string{"v1"}, Placeholder: "{id}"}
+	if got := NormalizeUrlPathWithOpts(&url.URL{Path: "/v1/v3/2024-01-01"}, opts); got != "/v1/{id}/{id}" {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestNormalizeUrlPathDecodeURLEncoding(t *testing.T) {
+	opts := DefaultNormalizeOpts
+	opts.DecodeURLEncoding = true
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/orders/%34%32", "/orders/-omitted-"},
+		{"/files/abc%2F123", "/files/abc/-omitted-"},
+		{"/files/abc%252F123", "/files/abc/-omitted-"},
+		{"/search/caf%C3%A9", "/search/café"},
+		{"/v1/orders%2F42/items/%7Bid%7D", "/v1/orders/-omitted-/items/{id}"},
+		{"/orders/100%", "/orders/-omitted-"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeUrlPathWithOpts(&url.URL{Path: tt.path}, opts); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+	if got := NormalizeUrlPathWithOpts(&url.URL{Path: "/search/caf%C3%A9"}, DefaultNormalizeOpts); got != "/search/-omitted-" {
+		t.Errorf("expected escaped parts to be kept without DecodeURLEncoding, got %q", got)
+	}
+}
+
+func TestNormalizeUrlPathFastPath(t *testing.T) {
+	paths := []string{"", "/", "/orders", "/orders/", "/api/v/users/me/", "//orders//items", "/orders/%7Bid%7D"}
+	for _, path := range paths {
+		if fast, full := NormalizeUrlPathForSentry(&url.URL{Path: path}, ""), normalizeUrlPathParts(path, DefaultNormalizeOpts); fast != full {
+			t.Errorf("%q: fast path %q differs from %q", path, fast, full)
+		}
+	}
+	opts := DefaultNormalizeOpts
+	opts.DateVersionRegex = regexp.MustCompile(`^latest$`)
+	if got := NormalizeUrlPathWithOpts(&url.URL{Path: "/api/latest/orders"}, opts); got != "/api/{date}/orders" {
+		t.Errorf("expected a custom DateVersionRegex to skip the fast path, got %q", got)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		NormalizeUrlPathForSentry(&url.URL{Path: "/api/orders/items/"}, "")
+	}); allocs != 0 {
+		t.Errorf("expected no allocation for a path without a number, got %v", allocs)
+	}
+}
+
+func TestNormalizeUrlPathCollapseAdjacentDuplicates(t *testing.T) {
+	opts := DefaultNormalizeOpts
+	opts.CollapseAdjacentDuplicates = true
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/api/v1/users", "/api/v1/users"},
+		{"/users/users/123", "/users/-omitted-"},
+		{"/api//api/v1//users", "/api/v1/users"},
+		{"/API/api/Users/users", "/API/Users"},
+		{"/items/items/items/7", "/items/-omitted-"},
+		{"/users/1/2", "/users/-omitted-/-omitted-"},
+		{"/users/alice", "/users/alice"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeUrlPathWithOpts(&url.URL{Path: tt.path}, opts); got != tt.want {
+			t.Errorf("%s: got %q want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := NormalizeUrlPathWithOpts(&url.URL{Path: "/api/api/v1"}, DefaultNormalizeOpts); got != "/api/api/v1" {
+		t.Errorf("expected duplicates to be kept by default %q", got)
+	}
+}
+
+func TestAtomicFingerprintOpts(t *testing.T) {
+	hubOrig, transport := sentrytest.NewRecordingHub(t)
+	atomicOpts, opts := NewAtomicFingerprintOpts(DefaultFingerprinter)
+	hub := HubCustomFingerprint(hubOrig, opts)
+
+	hub.CaptureException(SentryError500{Url: "/users/1", Body: "boom"})
+	salted := DefaultFingerprinter
+	salted.Salt = "staging"
+	atomicOpts.Store(salted.WithBeforeBreadcrumb(func(*sentry.Breadcrumb, *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+		return nil
+	}))
+	hub.AddBreadcrumb(&sentry.Breadcrumb{Message: "dropped"}, nil)
+	hub.CaptureException(SentryError500{Url: "/users/1", Body: "boom"})
+
+	if len(transport.Events()) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+	}
+	if fp := strings.Join(transport.Events()[0].Fingerprint, " "); fp != "/users/-omitted- boom" {
+		t.Errorf("unexpected %s", fp)
+	}
+	if fp := strings.Join(transport.Events()[1].Fingerprint, " "); fp != "staging:/users/-omitted- staging:boom" {
+		t.Errorf("unexpected %s", fp)
+	}
+	if len(transport.Events()[1].Breadcrumbs) != 0 {
+		t.Errorf("unexpected breadcrumbs %v", transport.Events()[1].Breadcrumbs)
+	}
+}
+
+func TestSentryError500JSON(t *testing.T) {
+	for _, e500 := range []SentryError500{
+		{Url: "https://example.com/users/1", Body: `{"error":"boom"}`},
+		{Url: "/orders", Body: "", ErrorName: "not_found"},
+		{Url: "/orders", Body: "nil map", PanicStack: "goroutine 1 [running]:\n"},
+	} {
+		data, err := json.Marshal(e500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]string
+		if err := json.Unmarshal(data, &fields); err != nil || fields["url"] != e500.Url || fields["body"] != e500.Body {
+			t.Errorf("unexpected json %s", data)
+		}
+		var decoded SentryError500
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != e500 {
+			t.Errorf("round trip %+v != %+v (%v)", decoded, e500, err)
+		}
+	}
+
+	hubOrig, transport := sentrytest.NewRecordingHub(t)
+	HubCustomFingerprint(hubOrig, DefaultFingerprinter).CaptureException(SentryError500{Url: "/users/1", Body: "boom"})
+	if len(transport.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.Events()))
+	}
+	data, err := json.Marshal(transport.Events()[0].Extra["sentry_error"])
+	if err != nil || string(data) != `{"url":"/users/1","body":"boom"}` {
+		t.Errorf("unexpected extra %s %v", data, err)
+	}
+}
+
+func BenchmarkNormalizeUrlPath(b *testing.B) {
+	for _, segments := range []int{5, 10, 20, 50} {
+		for _, numeric := range []bool{false, true} {
+			parts := make([]string, segments)
+			for i := range parts {
+				parts[i] = "orders"
+				if numeric && i%2 == 1 {
+					parts[i] = strconv.Itoa(1000 + i)
+				}
+			}
+			u := &url.URL{Path: "/" + strings.Join(parts, "/")}
+			b.Run(fmt.Sprintf("segments=%d/numeric=%t", segments, numeric), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					NormalizeUrlPathForSentry(u, "")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package sentrytest
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ScopeSnapshot is the scope data of an event at the time it was captured.
+type ScopeSnapshot struct {
+	Tags  map[string]string
+	User  sentry.User
+	Extra map[string]any
+	Level sentry.Level
+}
+
+// HubDouble records what is captured on the hub returned by NewDoubleHub, and on its clones.
+// Events are recorded once the scope is applied, before the BeforeSend of the client,
+// so the exceptions are the errors passed to CaptureException.
+type HubDouble struct {
+	mu         sync.Mutex
+	exceptions []error
+	messages   []string
+	events     []sentry.Event
+	scopes     []ScopeSnapshot
+}
+
+// NewDoubleHub returns a hub that sends nothing and a HubDouble recording its events.
+// Unlike sentry.NewHub(nil, nil), the hub goes through the whole capture path of a real client.
+func NewDoubleHub() (*sentry.Hub, *HubDouble) {
+	double := &HubDouble{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "https://key@o1.ingest.sentry.io/1",
+		Transport: &EventRecorder{},
+		// an integration is set up again on the clients that HubCustomFingerprint makes from the options
+		Integrations: func(integrations []sentry.Integration) []sentry.Integration {
+			return append(integrations, double)
+		},
+	})
+	if err != nil {
+		panic(err) // the DSN is valid
+	}
+	return sentry.NewHub(client, sentry.NewScope()), double
+}
+
+func (hd *HubDouble) Name() string { return "HubDouble" }
+
+func (hd *HubDouble) SetupOnce(client *sentry.Client) {
+	client.AddEventProcessor(hd.record)
+}
+
+func (hd *HubDouble) record(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	hd.events = append(hd.events, *event)
+	if hint != nil && hint.OriginalException != nil {
+		hd.exceptions = append(hd.exceptions, hint.OriginalException)
+		hd.scopes = append(hd.scopes, ScopeSnapshot{
+			Tags:  maps.Clone(event.Tags),
+			User:  event.User,
+			Extra: maps.Clone(event.Extra),
+			Level: event.Level,
+		})
+	} else if event.Message != "" {
+		hd.messages = append(hd.messages, event.Message)
+	}
+	return event
+}
+
+// CapturedExceptions returns the errors passed to CaptureException.
+func (hd *HubDouble) CapturedExceptions() []error {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	return append([]error(nil), hd.exceptions...)
+}
+
+// CapturedMessages returns the messages passed to CaptureMessage.
+func (hd *HubDouble) CapturedMessages() []string {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	return append([]string(nil), hd.messages...)
+}
+
+// CapturedEvents returns all captured events, including transactions.
+func (hd *HubDouble) CapturedEvents() []sentry.Event {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	return append([]sentry.Event(nil), hd.events...)
+}
+
+// ScopeHistory returns the scope of each CaptureException call, in the order of CapturedExceptions.
+func (hd *HubDouble) ScopeHistory() []ScopeSnapshot {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	return append([]ScopeSnapshot(nil), hd.scopes...)
+}
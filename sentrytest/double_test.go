@@ -0,0 +1,44 @@
+package sentrytest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestNewDoubleHub(t *testing.T) {
+	hub, double := NewDoubleHub()
+	errFirst := errors.New("first")
+	hub.Scope().SetTag("step", "1")
+	hub.CaptureException(errFirst)
+
+	clone := hub.Clone()
+	clone.Scope().SetTag("step", "2")
+	clone.Scope().SetUser(sentry.User{ID: "42"})
+	clone.Scope().SetExtra("attempt", 3)
+	clone.Scope().SetLevel(sentry.LevelFatal)
+	clone.CaptureException(errors.New("second"))
+	hub.CaptureMessage("done")
+
+	if exceptions := double.CapturedExceptions(); len(exceptions) != 2 || exceptions[0] != errFirst {
+		t.Errorf("unexpected exceptions %v", exceptions)
+	}
+	if messages := double.CapturedMessages(); len(messages) != 1 || messages[0] != "done" {
+		t.Errorf("unexpected messages %v", messages)
+	}
+	if events := double.CapturedEvents(); len(events) != 3 {
+		t.Errorf("expected 3 events, got %d", len(events))
+	}
+	history := double.ScopeHistory()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(history))
+	}
+	if history[0].Tags["step"] != "1" || history[0].User.ID != "" || history[0].Level != sentry.LevelError {
+		t.Errorf("unexpected first snapshot %+v", history[0])
+	}
+	if history[1].Tags["step"] != "2" || history[1].User.ID != "42" || history[1].Extra["attempt"] != 3 ||
+		history[1].Level != sentry.LevelFatal {
+		t.Errorf("unexpected second snapshot %+v", history[1])
+	}
+}
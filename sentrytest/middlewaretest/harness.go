@@ -0,0 +1,93 @@
+// Package middlewaretest runs the Gin and Goa middlewares against a recording hub.
+// It is separate from sentrytest so that sentrytest does not depend on Gin and Goa.
+package middlewaretest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentrygin "github.com/digitalmint/go-sentry-middleware/gin"
+	mdlwrsentrygoa "github.com/digitalmint/go-sentry-middleware/goa"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// MiddlewareHarness serves a single request through a middleware with a recording hub in the request context.
+type MiddlewareHarness struct {
+	t      testing.TB
+	hub    *sentry.Hub
+	events *sentrytest.EventRecorder
+	// Request is served by ServeGin and ServeGoa, GET / by default
+	Request func() *http.Request
+}
+
+// NewMiddlewareHarness returns a harness whose hub must be flushed when the test finishes.
+func NewMiddlewareHarness(t testing.TB) *MiddlewareHarness {
+	t.Helper()
+	hub, events := sentrytest.NewRecordingHub(t)
+	h := &MiddlewareHarness{
+		t:      t,
+		hub:    hub,
+		events: events,
+		Request: func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/", nil)
+		},
+	}
+	t.Cleanup(func() {
+		if !hub.Flush(time.Second) {
+			t.Error("the hub was not flushed")
+		}
+	})
+	return h
+}
+
+// Events returns the recorder of the events captured by the middleware.
+func (h *MiddlewareHarness) Events() *sentrytest.EventRecorder {
+	return h.events
+}
+
+// request returns the request with a clone of the hub, like sentry-go's http integration does.
+func (h *MiddlewareHarness) request() *http.Request {
+	r := h.Request()
+	return r.WithContext(sentry.SetHubOnContext(r.Context(), h.hub.Clone()))
+}
+
+func (h *MiddlewareHarness) ServeGin(opts sentrygin.Sentry500Options, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sentrygin.MiddlewareSentry500Opts(opts))
+	engine.NoRoute(handler)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, h.request())
+	return rec
+}
+
+func (h *MiddlewareHarness) ServeGoa(opts mdlwrsentrygoa.Sentry500Options, handler http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mdlwrsentrygoa.MiddlewareSentry500(opts)(handler).ServeHTTP(rec, h.request())
+	return rec
+}
+
+// AssertEventCaptured fails the test unless an event matches, and returns the first match.
+func (h *MiddlewareHarness) AssertEventCaptured(t testing.TB, matcher sentrytest.EventMatcher) *sentry.Event {
+	t.Helper()
+	events := h.events.Events()
+	for _, event := range events {
+		if matcher(event) {
+			return event
+		}
+	}
+	t.Errorf("no matching event in %d captured events", len(events))
+	return nil
+}
+
+// AssertNoEvent fails the test if an event was captured.
+func (h *MiddlewareHarness) AssertNoEvent(t testing.TB) {
+	t.Helper()
+	if events := h.events.Events(); len(events) != 0 {
+		t.Errorf("expected no event, got %d", len(events))
+	}
+}
@@ -0,0 +1,80 @@
+package middlewaretest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mdlwrsentry "github.com/digitalmint/go-sentry-middleware"
+	sentrygin "github.com/digitalmint/go-sentry-middleware/gin"
+	mdlwrsentrygoa "github.com/digitalmint/go-sentry-middleware/goa"
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeGin(t *testing.T) {
+	h := NewMiddlewareHarness(t)
+	h.Request = func() *http.Request { return httptest.NewRequest(http.MethodPost, "/orders/7", nil) }
+	opts := sentrygin.DefaultSentry500Opts
+	opts.CaptureAsMessage = []int{http.StatusUnprocessableEntity}
+
+	rec := h.ServeGin(opts, func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, "database unavailable")
+	})
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+	h.AssertEventCaptured(t, sentrytest.MatchAll(
+		sentrytest.MatchLevel(sentry.LevelError),
+		sentrytest.MatchException("/orders/7:database unavailable"),
+		sentrytest.MatchTag(mdlwrsentry.CaptureSourceTag, string(mdlwrsentry.CaptureSourceGinMiddleware500)),
+	))
+
+	h.ServeGin(opts, func(c *gin.Context) { c.Status(http.StatusUnprocessableEntity) })
+	h.AssertEventCaptured(t, sentrytest.MatchAll(
+		sentrytest.MatchLevel(sentry.LevelWarning),
+		sentrytest.MatchMessage("HTTP 422"),
+	))
+}
+
+func TestServeGoa(t *testing.T) {
+	h := NewMiddlewareHarness(t)
+	h.ServeGoa(mdlwrsentrygoa.DefaultSentry500Opts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	h.AssertNoEvent(t)
+
+	opts := mdlwrsentrygoa.DefaultSentry500Opts
+	opts.HubModifiers = []mdlwrsentry.HubModifier{mdlwrsentry.RequestTagModifier([]string{"X-Request-Id"})}
+	h.Request = func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Request-Id", "req-1")
+		return r
+	}
+	h.ServeGoa(opts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	h.AssertEventCaptured(t, sentrytest.MatchAll(
+		sentrytest.MatchTag("X-Request-Id", "req-1"),
+		sentrytest.MatchTag(mdlwrsentry.CaptureSourceTag, string(mdlwrsentry.CaptureSourceGoaMiddleware500)),
+	))
+}
+
+func TestServeGinRoutePatternCulprit(t *testing.T) {
+	h := NewMiddlewareHarness(t)
+	h.Request = func() *http.Request { return httptest.NewRequest(http.MethodGet, "/orders/7", nil) }
+	opts := sentrygin.DefaultSentry500Opts
+	opts.CulpritExtractor = mdlwrsentry.RoutePatternCulpritExtractor
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sentrygin.MiddlewareSentry500Opts(opts))
+	engine.GET("/orders/:id", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	engine.ServeHTTP(httptest.NewRecorder(), h.request())
+
+	event := h.AssertEventCaptured(t, sentrytest.MatchException("/orders/7"))
+	if event.Transaction != "/orders/:id" {
+		t.Errorf("expected the gin route as the transaction, got %q", event.Transaction)
+	}
+}
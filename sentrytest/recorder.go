@@ -0,0 +1,95 @@
+package sentrytest
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// EventRecorder is a sentry.Transport that keeps the events in memory.
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+	// pending counts the SendEvent calls in progress
+	pending atomic.Int64
+}
+
+// Flush waits for the SendEvent calls in progress, e.g. from a capture in another goroutine.
+// It returns false when they did not finish within the timeout.
+func (er *EventRecorder) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for er.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func (er *EventRecorder) Configure(_ sentry.ClientOptions) {}
+func (er *EventRecorder) Close()                           {}
+func (er *EventRecorder) SendEvent(event *sentry.Event) {
+	er.pending.Add(1)
+	defer er.pending.Add(-1)
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.events = append(er.events, event)
+}
+
+// Events returns the events sent so far.
+func (er *EventRecorder) Events() []*sentry.Event {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	return append([]*sentry.Event(nil), er.events...)
+}
+
+// NewRecordingHub returns a hub whose events are kept by the returned EventRecorder.
+func NewRecordingHub(t testing.TB) (*sentry.Hub, *EventRecorder) {
+	t.Helper()
+	recorder := &EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@o1.ingest.sentry.io/1", Transport: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), recorder
+}
+
+// EventMatcher selects events in assertions.
+type EventMatcher func(*sentry.Event) bool
+
+// MatchAll matches events that match every matcher.
+func MatchAll(matchers ...EventMatcher) EventMatcher {
+	return func(event *sentry.Event) bool {
+		for _, matcher := range matchers {
+			if !matcher(event) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func MatchLevel(level sentry.Level) EventMatcher {
+	return func(event *sentry.Event) bool { return event.Level == level }
+}
+
+func MatchTag(key, value string) EventMatcher {
+	return func(event *sentry.Event) bool { return event.Tags[key] == value }
+}
+
+// MatchException matches events whose last exception value contains substr.
+func MatchException(substr string) EventMatcher {
+	return func(event *sentry.Event) bool {
+		return len(event.Exception) > 0 && strings.Contains(event.Exception[len(event.Exception)-1].Value, substr)
+	}
+}
+
+// MatchMessage matches events whose message contains substr.
+func MatchMessage(substr string) EventMatcher {
+	return func(event *sentry.Event) bool { return strings.Contains(event.Message, substr) }
+}
@@ -0,0 +1,54 @@
+package sentrytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestEventRecorderMatchers(t *testing.T) {
+	hub, recorder := NewRecordingHub(t)
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("job", "import")
+		hub.CaptureException(errors.New("connection refused"))
+	})
+	hub.CaptureMessage("done")
+
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if !MatchAll(MatchTag("job", "import"), MatchException("refused"), MatchLevel(sentry.LevelError))(events[0]) {
+		t.Errorf("expected the exception to match")
+	}
+	if MatchException("refused")(events[1]) || !MatchMessage("done")(events[1]) {
+		t.Errorf("unexpected match of the message")
+	}
+}
+
+func TestEventRecorderFlush(t *testing.T) {
+	recorder := &EventRecorder{}
+	// a send blocked on the lock is in progress
+	recorder.mu.Lock()
+	sent := make(chan struct{})
+	go func() {
+		recorder.SendEvent(&sentry.Event{Message: "late"})
+		close(sent)
+	}()
+	for recorder.pending.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if recorder.Flush(10 * time.Millisecond) {
+		t.Errorf("expected the flush to time out while a send is in progress")
+	}
+	recorder.mu.Unlock()
+	if !recorder.Flush(time.Second) {
+		t.Errorf("expected the flush to wait for the send")
+	}
+	<-sent
+	if len(recorder.Events()) != 1 {
+		t.Errorf("expected the event to be recorded")
+	}
+}
@@ -0,0 +1,93 @@
+package sentrytest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// CapturedScopeRecorder exposes the state of a hub scope after middleware has run.
+// sentry.Scope keeps its fields private, so the state is read back by applying the scope to an empty event.
+type CapturedScopeRecorder struct {
+	hub *sentry.Hub
+}
+
+// CapturedScope wraps the scope of the given hub so that tests can assert on what handlers set on it.
+func CapturedScope(hub *sentry.Hub) *CapturedScopeRecorder {
+	return &CapturedScopeRecorder{hub: hub}
+}
+
+func (csr *CapturedScopeRecorder) snapshot() *sentry.Event {
+	event := csr.hub.Scope().ApplyToEvent(sentry.NewEvent(), nil, nil)
+	if event == nil {
+		// an event processor on the scope dropped the event
+		return sentry.NewEvent()
+	}
+	return event
+}
+
+// User returns the user set with SetUser
+func (csr *CapturedScopeRecorder) User() sentry.User {
+	return csr.snapshot().User
+}
+
+// Tags returns the tags set with SetTag or SetTags
+func (csr *CapturedScopeRecorder) Tags() map[string]string {
+	return csr.snapshot().Tags
+}
+
+// Extra returns the extra data set with SetExtra or SetExtras
+func (csr *CapturedScopeRecorder) Extra() map[string]any {
+	return csr.snapshot().Extra
+}
+
+// Request returns the request set with SetRequest, or nil
+func (csr *CapturedScopeRecorder) Request() *sentry.Request {
+	return csr.snapshot().Request
+}
+
+// Level returns the level set with SetLevel
+func (csr *CapturedScopeRecorder) Level() sentry.Level {
+	return csr.snapshot().Level
+}
+
+// SerializeScope returns the tags, user, extra, level, transaction and contexts of the hub scope
+// as JSON values, for debug logging or to show the scope when an assertion fails.
+// The error is that of json.Marshal, e.g. for an extra value that cannot be marshaled.
+func SerializeScope(hub *sentry.Hub) (map[string]any, error) {
+	event := CapturedScope(hub).snapshot()
+	data, err := json.Marshal(map[string]any{
+		"tags":        event.Tags,
+		"user":        event.User,
+		"extra":       event.Extra,
+		"level":       event.Level,
+		"transaction": event.Transaction,
+		"contexts":    event.Contexts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var scope map[string]any
+	if err := json.Unmarshal(data, &scope); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+// AssertScopeTag fails the test when the hub scope does not have the tag with the value,
+// the message shows the whole scope.
+func AssertScopeTag(t testing.TB, hub *sentry.Hub, key, value string) {
+	t.Helper()
+	got, ok := CapturedScope(hub).Tags()[key]
+	if ok && got == value {
+		return
+	}
+	scope, err := SerializeScope(hub)
+	if err != nil {
+		t.Errorf("expected the tag %s=%q, the scope cannot be serialized: %v", key, value, err)
+		return
+	}
+	data, _ := json.MarshalIndent(scope, "", "  ")
+	t.Errorf("expected the tag %s=%q, got the scope %s", key, value, data)
+}
@@ -0,0 +1,87 @@
+package sentrytest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestCapturedScope(t *testing.T) {
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	hub.Scope().SetUser(sentry.User{ID: "user-1"})
+	hub.Scope().SetTag("tenant", "acme")
+	hub.Scope().SetExtra("attempt", 2)
+
+	recorder := CapturedScope(hub)
+	if recorder.User().ID != "user-1" {
+		t.Errorf("unexpected user %v", recorder.User())
+	}
+	if recorder.Tags()["tenant"] != "acme" {
+		t.Errorf("unexpected tags %v", recorder.Tags())
+	}
+	if recorder.Extra()["attempt"] != 2 {
+		t.Errorf("unexpected extra %v", recorder.Extra())
+	}
+	if recorder.Request() != nil {
+		t.Errorf("unexpected request %v", recorder.Request())
+	}
+}
+
+func TestSerializeScope(t *testing.T) {
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	hub.Scope().SetUser(sentry.User{ID: "user-1"})
+	hub.Scope().SetTag("tenant", "acme")
+	hub.Scope().SetExtra("attempt", 2)
+	hub.Scope().SetLevel(sentry.LevelWarning)
+	hub.Scope().SetContext("order", sentry.Context{"id": "o-1"})
+	hub.Scope().AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		event.Transaction = "GET /orders"
+		return event
+	})
+
+	scope, err := SerializeScope(hub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scope["tags"].(map[string]any)["tenant"] != "acme" || scope["user"].(map[string]any)["id"] != "user-1" {
+		t.Errorf("unexpected tags or user %v", scope)
+	}
+	if scope["extra"].(map[string]any)["attempt"] != 2.0 || scope["level"] != "warning" {
+		t.Errorf("unexpected extra or level %v", scope)
+	}
+	if scope["transaction"] != "GET /orders" || scope["contexts"].(map[string]any)["order"].(map[string]any)["id"] != "o-1" {
+		t.Errorf("unexpected transaction or contexts %v", scope)
+	}
+
+	hub.Scope().SetExtra("done", make(chan struct{}))
+	if _, err := SerializeScope(hub); err == nil {
+		t.Error("expected an error for an extra that cannot be marshaled")
+	}
+}
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertScopeTag(t *testing.T) {
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	hub.Scope().SetTag("tenant", "acme")
+
+	tb := &recordingTB{TB: t}
+	AssertScopeTag(tb, hub, "tenant", "acme")
+	if len(tb.errors) != 0 {
+		t.Errorf("unexpected failure %v", tb.errors)
+	}
+	AssertScopeTag(tb, hub, "tenant", "globex")
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], `"tenant": "acme"`) {
+		t.Errorf("expected the failure to show the scope %v", tb.errors)
+	}
+}
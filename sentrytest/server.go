@@ -0,0 +1,168 @@
+package sentrytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/internal/envelope"
+	"github.com/getsentry/sentry-go"
+)
+
+// Server is a fake Sentry ingest server that stores the events it receives.
+// Point a client at it with Server.DSN. Malformed events fail the test when the server is closed.
+type Server struct {
+	t      testing.TB
+	server *httptest.Server
+
+	mu       sync.Mutex
+	events   []sentry.Event
+	waited   int
+	received chan struct{} // closed and replaced whenever an event is stored
+	// rejected are the errors of the malformed events, reported by Close from the test goroutine
+	rejected []error
+}
+
+// NewServer starts a Server that is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	s := &Server{t: t, received: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/{projectID}/store/", s.handleStore)
+	mux.HandleFunc("POST /api/{projectID}/envelope/", s.handleEnvelope)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// DSN returns a DSN that sends events to this server.
+func (s *Server) DSN() string {
+	return strings.Replace(s.server.URL, "http://", "http://public@", 1) + "/1"
+}
+
+// Events returns all events received so far.
+func (s *Server) Events() []sentry.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentry.Event(nil), s.events...)
+}
+
+// WaitForEvent returns the next event that was not yet returned by WaitForEvent,
+// waiting for it to arrive until ctx is done.
+func (s *Server) WaitForEvent(ctx context.Context) (sentry.Event, error) {
+	for {
+		s.mu.Lock()
+		if s.waited < len(s.events) {
+			event := s.events[s.waited]
+			s.waited++
+			s.mu.Unlock()
+			return event, nil
+		}
+		received := s.received
+		s.mu.Unlock()
+
+		select {
+		case <-received:
+		case <-ctx.Done():
+			return sentry.Event{}, ctx.Err()
+		}
+	}
+}
+
+// Close stops the server, waiting for the requests in flight, and fails the test with the malformed events it received.
+// It is called when the test finishes.
+func (s *Server) Close() {
+	s.server.Close()
+	s.mu.Lock()
+	rejected := s.rejected
+	s.rejected = nil
+	s.mu.Unlock()
+	for _, err := range rejected {
+		s.t.Errorf("sentrytest.Server received an invalid event: %v", err)
+	}
+}
+
+func (s *Server) store(event sentry.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	close(s.received)
+	s.received = make(chan struct{})
+}
+
+func (s *Server) handleStore(w http.ResponseWriter, r *http.Request) {
+	event, err := decodeEvent(r.Body)
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	s.store(event)
+	writeEventID(w, event.EventID)
+}
+
+func (s *Server) handleEnvelope(w http.ResponseWriter, r *http.Request) {
+	events, err := decodeEnvelope(r.Body)
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	for _, event := range events {
+		s.store(event)
+	}
+	var eventID sentry.EventID
+	if len(events) > 0 {
+		eventID = events[0].EventID
+	}
+	writeEventID(w, eventID)
+}
+
+// reject runs on a server goroutine, the error is reported by Close.
+func (s *Server) reject(w http.ResponseWriter, err error) {
+	s.mu.Lock()
+	s.rejected = append(s.rejected, err)
+	s.mu.Unlock()
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func writeEventID(w http.ResponseWriter, eventID sentry.EventID) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]sentry.EventID{"id": eventID})
+}
+
+func decodeEvent(r io.Reader) (sentry.Event, error) {
+	event := sentry.Event{}
+	if err := json.NewDecoder(r).Decode(&event); err != nil {
+		return event, err
+	}
+	if event.EventID == "" {
+		return event, errors.New("missing event_id")
+	}
+	return event, nil
+}
+
+// decodeEnvelope returns the event and transaction items of an envelope.
+func decodeEnvelope(r io.Reader) ([]sentry.Event, error) {
+	items, err := envelope.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	var events []sentry.Event
+	for _, item := range items {
+		if item.Type != "event" && item.Type != "transaction" {
+			continue
+		}
+		event, err := decodeEvent(bytes.NewReader(item.Payload))
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s item: %w", item.Type, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,80 @@
+package sentrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer(t)
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: server.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	hub.Scope().SetTag("component", "test")
+	eventID := hub.CaptureException(errors.New("over the wire"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	event, err := server.WaitForEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.EventID != *eventID {
+		t.Errorf("unexpected event id %s", event.EventID)
+	}
+	if len(event.Exception) != 1 || event.Exception[0].Value != "over the wire" {
+		t.Errorf("unexpected exception %v", event.Exception)
+	}
+	if event.Tags["component"] != "test" {
+		t.Errorf("unexpected tags %v", event.Tags)
+	}
+	if len(server.Events()) != 1 {
+		t.Errorf("unexpected events %v", server.Events())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := server.WaitForEvent(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected no more events %v", err)
+	}
+}
+
+// errorRecordingTB records Errorf instead of failing the test.
+type errorRecordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *errorRecordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServerRejectsInvalidEvents(t *testing.T) {
+	tb := &errorRecordingTB{TB: t}
+	server := NewServer(tb)
+	res, err := http.Post(server.server.URL+"/api/1/store/", "application/json", strings.NewReader("not json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected status %d", res.StatusCode)
+	}
+	if len(tb.errors) != 0 {
+		t.Errorf("expected the rejection to be reported by Close, got %v", tb.errors)
+	}
+	server.Close()
+	server.Close()
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "invalid event") {
+		t.Errorf("expected the rejection to be reported once, got %v", tb.errors)
+	}
+}
@@ -0,0 +1,36 @@
+package sentry
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// captureSuppression is shared by pointer so that a handler can suppress the capture of a middleware
+// that only sees the context it passed down.
+type captureSuppression struct {
+	suppressed atomic.Bool
+}
+
+// WithSuppressibleCapture lets SuppressSentryCapture called on a derived context be seen with this context.
+// The middlewares call it before the handler.
+func WithSuppressibleCapture(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressKey, &captureSuppression{})
+}
+
+// SuppressSentryCapture stops the middlewares from sending the 500 of this request to Sentry,
+// for a code path that returns a 500 on purpose.
+func SuppressSentryCapture(ctx context.Context) context.Context {
+	if s, ok := ctx.Value(suppressKey).(*captureSuppression); ok {
+		s.suppressed.Store(true)
+		return ctx
+	}
+	s := &captureSuppression{}
+	s.suppressed.Store(true)
+	return context.WithValue(ctx, suppressKey, s)
+}
+
+// IsSentrySuppressed reports whether SuppressSentryCapture was called for the request.
+func IsSentrySuppressed(ctx context.Context) bool {
+	s, ok := ctx.Value(suppressKey).(*captureSuppression)
+	return ok && s.suppressed.Load()
+}
@@ -0,0 +1,25 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSuppressSentryCapture(t *testing.T) {
+	ctx := WithSuppressibleCapture(context.Background())
+	handlerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if IsSentrySuppressed(ctx) {
+		t.Error("expected no suppression before SuppressSentryCapture")
+	}
+	SuppressSentryCapture(handlerCtx)
+	if !IsSentrySuppressed(ctx) {
+		t.Error("expected the suppression of a derived context to be seen by the middleware")
+	}
+	if IsSentrySuppressed(WithSuppressibleCapture(context.Background())) {
+		t.Error("expected the suppression not to leak to other requests")
+	}
+	if !IsSentrySuppressed(SuppressSentryCapture(context.Background())) {
+		t.Error("expected SuppressSentryCapture to work without WithSuppressibleCapture")
+	}
+}
@@ -0,0 +1,45 @@
+package sentry
+
+import "time"
+
+type temporalFingerprintConfig struct {
+	now func() time.Time
+}
+
+// TemporalFingerprintOption configures TemporalFingerprinter.
+type TemporalFingerprintOption func(*temporalFingerprintConfig)
+
+// WithNowFunc sets the clock of TemporalFingerprinter, time.Now by default.
+func WithNowFunc(now func() time.Time) TemporalFingerprintOption {
+	return func(config *temporalFingerprintConfig) {
+		config.now = now
+	}
+}
+
+// TemporalFingerprinter groups the events of a recurring incident, e.g. a nightly batch failure,
+// into one issue per period of granularity: the UTC start of the period is appended to base.
+// Periods of whole days are formatted as 2006-01-02, shorter ones as RFC 3339.
+// An empty base keeps the current fingerprint, or the default grouping when there is none.
+// A granularity that is not positive appends nothing.
+func TemporalFingerprinter(base []string, granularity time.Duration, opts ...TemporalFingerprintOption) Fingerprint {
+	config := temporalFingerprintConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	layout := time.RFC3339
+	if granularity%(24*time.Hour) == 0 {
+		layout = "2006-01-02"
+	}
+	return func(_ error, fingerprint []string) ([]string, error) {
+		if len(base) > 0 {
+			fingerprint = base
+		} else if len(fingerprint) == 0 {
+			fingerprint = []string{"{{ default }}"}
+		}
+		if granularity <= 0 {
+			return fingerprint, nil
+		}
+		bucket := config.now().UTC().Truncate(granularity).Format(layout)
+		return append(fingerprint[:len(fingerprint):len(fingerprint)], bucket), nil
+	}
+}
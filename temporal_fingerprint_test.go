@@ -0,0 +1,52 @@
+package sentry
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTemporalFingerprinter(t *testing.T) {
+	now := time.Date(2024, 3, 9, 23, 59, 59, 0, time.UTC)
+	base := []string{"nightly-batch"}
+	fingerprinter := TemporalFingerprinter(base, 24*time.Hour, WithNowFunc(func() time.Time { return now }))
+
+	before, err := fingerprinter(errors.New("batch failed"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nightly-batch", "2024-03-09"}; !slices.Equal(before, want) {
+		t.Errorf("expected %v, got %v", want, before)
+	}
+	now = now.Add(2 * time.Second)
+	after, _ := fingerprinter(errors.New("batch failed"), nil)
+	if want := []string{"nightly-batch", "2024-03-10"}; !slices.Equal(after, want) {
+		t.Errorf("expected %v after midnight, got %v", want, after)
+	}
+	if !slices.Equal(base, []string{"nightly-batch"}) {
+		t.Errorf("base was modified %v", base)
+	}
+}
+
+func TestTemporalFingerprinterCurrentFingerprint(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 3, 9, 14, 35, 0, 0, time.FixedZone("CET", 3600)) }
+	tests := []struct {
+		granularity time.Duration
+		current     []string
+		want        []string
+	}{
+		{time.Hour, []string{"timeout", "/orders"}, []string{"timeout", "/orders", "2024-03-09T13:00:00Z"}},
+		{24 * time.Hour, nil, []string{"{{ default }}", "2024-03-09"}},
+		{0, []string{"timeout"}, []string{"timeout"}},
+	}
+	for _, test := range tests {
+		got, err := TemporalFingerprinter(nil, test.granularity, WithNowFunc(now))(nil, test.current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.granularity, test.want, got)
+		}
+	}
+}
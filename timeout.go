@@ -0,0 +1,66 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// TimeoutOptions configures MiddlewareSentryTimeout.
+type TimeoutOptions struct {
+	Timeout time.Duration
+	// Deprecated: use HubModifiers, ExtractContext is applied before them.
+	ExtractContext  func(context.Context, *sentry.Scope)
+	HubModifiers    []HubModifier
+	FingerprintOpts FingerprintOpts
+}
+
+// MiddlewareSentryTimeout is a net/http middleware that runs the handler with a context deadline of opts.Timeout.
+// If the deadline is exceeded a SentryErrorTimeout is sent to Sentry.
+// The handler must respect context cancellation: the middleware waits for it to return.
+// A Timeout <= 0 means no timeout: the handler runs with the request context unchanged.
+func MiddlewareSentryTimeout(opts TimeoutOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if opts.Timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), opts.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			next.ServeHTTP(w, r.WithContext(ctx))
+			elapsed := time.Since(start)
+
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+			hubOrig := sentry.GetHubFromContext(ctx)
+			if hubOrig == nil {
+				hubOrig = sentry.CurrentHub().Clone()
+			}
+			hub := HubCustomFingerprint(hubOrig, opts.FingerprintOpts)
+			hub.Scope().SetRequest(r)
+			urlStr := ""
+			if url := r.URL; url != nil {
+				urlStr = url.String()
+			}
+
+			modifiers := []HubModifier{ContextTagsModifier}
+			if opts.ExtractContext != nil {
+				modifiers = append(modifiers, LegacyExtractContextModifier(opts.ExtractContext))
+			}
+			modifiers = append(modifiers, opts.HubModifiers...)
+			ApplyHubModifiers(ContextWithRequest(ctx, r), hub, modifiers)
+
+			hub.CaptureException(SentryErrorTimeout{
+				Url:     urlStr,
+				Timeout: opts.Timeout,
+				Elapsed: elapsed,
+			})
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package sentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func deadlineHandler(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(50 * time.Millisecond):
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestMiddlewareSentryTimeout(t *testing.T) {
+	hub, recorder := sentrytest.NewRecordingHub(t)
+	handler := MiddlewareSentryTimeout(TimeoutOptions{Timeout: 5 * time.Millisecond, FingerprintOpts: DefaultFingerprinter})(http.HandlerFunc(deadlineHandler))
+	req := httptest.NewRequest(http.MethodGet, "/reports/9", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if fp := events[0].Fingerprint; len(fp) != 3 || fp[0] != "timeout" || fp[2] != "5ms" {
+		t.Errorf("unexpected fingerprint %v", fp)
+	}
+}
+
+func TestMiddlewareSentryTimeoutNonPositive(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			hub, recorder := sentrytest.NewRecordingHub(t)
+			var hasDeadline bool
+			handler := MiddlewareSentryTimeout(TimeoutOptions{Timeout: timeout})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hasDeadline = r.Context().Deadline()
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/reports/9", nil)
+			req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if hasDeadline {
+				t.Errorf("expected no deadline")
+			}
+			if rec.Code != http.StatusOK || len(recorder.Events()) != 0 {
+				t.Errorf("unexpected status %d and %d events", rec.Code, len(recorder.Events()))
+			}
+		})
+	}
+}
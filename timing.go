@@ -0,0 +1,85 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// RequestTiming is shared through the request context between the slow request middleware and the 500 middlewares.
+type RequestTiming struct {
+	Start     time.Time
+	Threshold time.Duration
+}
+
+func ContextWithRequestTiming(ctx context.Context, timing *RequestTiming) context.Context {
+	return context.WithValue(ctx, requestTimingKey, timing)
+}
+
+// RequestTimingFromContext returns the timing stored by ContextWithRequestTiming or nil.
+func RequestTimingFromContext(ctx context.Context) *RequestTiming {
+	timing, _ := ctx.Value(requestTimingKey).(*RequestTiming)
+	return timing
+}
+
+// SlowRequestOpts configures the capture of slow requests by the 500 middlewares.
+type SlowRequestOpts struct {
+	Threshold time.Duration
+	// Level defaults to sentry.LevelWarning
+	Level sentry.Level
+}
+
+// CaptureSlowRequest sends a "Slow request" message grouped on the normalized URL of the request.
+func CaptureSlowRequest(hub *sentry.Hub, r *http.Request, elapsed time.Duration, opts SlowRequestOpts) {
+	level := opts.Level
+	if level == "" {
+		level = sentry.LevelWarning
+	}
+	event := sentry.NewEvent()
+	event.Level = level
+	event.Message = fmt.Sprintf("Slow request: %s on %s", elapsed, SanitizeURL(r.URL))
+	event.Request = sentry.NewRequest(r)
+	event.Fingerprint = []string{"slow-request", NormalizeUrlPathForSentry(r.URL, "")}
+	event.Extra["elapsed_ms"] = elapsed.Milliseconds()
+	event.Extra["threshold_ms"] = opts.Threshold.Milliseconds()
+	hub.CaptureEvent(event)
+}
+
+// Elapsed is the time since the request started.
+func (rt *RequestTiming) Elapsed() time.Duration {
+	return time.Since(rt.Start)
+}
+
+// SlowBreadcrumb returns a breadcrumb if the request is over the threshold, otherwise nil.
+// A 500 after slow processing usually has a different cause than a fast one.
+func (rt *RequestTiming) SlowBreadcrumb() *sentry.Breadcrumb {
+	elapsed := rt.Elapsed()
+	if elapsed <= rt.Threshold {
+		return nil
+	}
+	return &sentry.Breadcrumb{
+		Type:     "default",
+		Category: "slow_request",
+		Level:    sentry.LevelWarning,
+		Message:  "request took " + elapsed.String() + ", over the " + rt.Threshold.String() + " threshold",
+		Data: map[string]any{
+			"elapsed_ms":   elapsed.Milliseconds(),
+			"threshold_ms": rt.Threshold.Milliseconds(),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// AddSlowRequestBreadcrumb adds the slow request breadcrumb to the hub when the context has a slow request timing.
+func AddSlowRequestBreadcrumb(ctx context.Context, hub *sentry.Hub) {
+	timing := RequestTimingFromContext(ctx)
+	if timing == nil {
+		return
+	}
+	if breadcrumb := timing.SlowBreadcrumb(); breadcrumb != nil {
+		hub.AddBreadcrumb(breadcrumb, nil)
+	}
+}
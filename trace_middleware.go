@@ -0,0 +1,41 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// PropagateTraceMiddleware is a net/http middleware, usable with Goa and with gin through gin.WrapH,
+// that continues the trace of the sentry-trace and baggage headers of the incoming request.
+// The request gets a clone of hub, nil means the current hub, and an "http.server" transaction named after
+// the normalized route, e.g. "GET /orders/-omitted-". Errors captured during the request get the same transaction name.
+// Use NewSentryTraceTransport in the client of outgoing requests to propagate the trace to the next service.
+func PropagateTraceMiddleware(hub *sentry.Hub) func(http.Handler) http.Handler {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestHub := hub.Clone()
+			requestHub.Scope().SetRequest(r)
+			ctx := sentry.SetHubOnContext(r.Context(), requestHub)
+
+			route := NormalizeUrlPathForSentry(r.URL, "")
+			if route == "" {
+				route = "/"
+			}
+			name := r.Method + " " + route
+			TransactionModifier(func(context.Context) string { return name }).ModifyHub(ctx, requestHub)
+
+			tx := sentry.StartTransaction(ctx, name,
+				sentry.ContinueFromRequest(r),
+				sentry.WithOpName("http.server"),
+				sentry.WithTransactionSource(sentry.SourceRoute),
+			)
+			defer tx.Finish()
+			next.ServeHTTP(w, r.WithContext(tx.Context()))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package sentry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestPropagateTraceMiddlewareBetweenServices(t *testing.T) {
+	backendHub, backendEvents := newTracingHub(t)
+	backend := httptest.NewServer(PropagateTraceMiddleware(backendHub)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentry.GetHubFromContext(r.Context()).CaptureException(errors.New("order not found"))
+		w.WriteHeader(http.StatusNotFound)
+	})))
+	defer backend.Close()
+
+	frontendHub, frontendEvents := newTracingHub(t)
+	client := &http.Client{Transport: NewSentryTraceTransport(nil)}
+	frontend := httptest.NewServer(PropagateTraceMiddleware(frontendHub)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, backend.URL+"/orders/42", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+		w.WriteHeader(res.StatusCode)
+	})))
+	defer frontend.Close()
+
+	res, err := http.Get(frontend.URL + "/checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if len(frontendEvents.Events()) != 1 || len(frontendEvents.Events()[0].Spans) != 1 {
+		t.Fatalf("expected a frontend transaction with the http.client span %v", frontendEvents.Events())
+	}
+	frontendTx := frontendEvents.Events()[0]
+	if frontendTx.Transaction != "GET /checkout" {
+		t.Errorf("unexpected frontend transaction %q", frontendTx.Transaction)
+	}
+	if len(backendEvents.Events()) != 2 {
+		t.Fatalf("expected the error and the transaction of the backend %v", backendEvents.Events())
+	}
+	backendErr, backendTx := backendEvents.Events()[0], backendEvents.Events()[1]
+	frontendTrace := frontendTx.Contexts["trace"]
+	backendTrace := backendTx.Contexts["trace"]
+	if backendTrace["trace_id"] != frontendTrace["trace_id"] {
+		t.Errorf("trace not continued %v %v", backendTrace, frontendTrace)
+	}
+	if backendTrace["parent_span_id"] != frontendTx.Spans[0].SpanID {
+		t.Errorf("expected the backend transaction to be a child of the http.client span %v", backendTrace)
+	}
+	if backendTx.Transaction != "GET /orders/-omitted-" || backendErr.Transaction != "GET /orders/-omitted-" {
+		t.Errorf("unexpected backend transactions %q %q", backendTx.Transaction, backendErr.Transaction)
+	}
+	if backendErr.Contexts["trace"]["trace_id"] != frontendTrace["trace_id"] {
+		t.Errorf("expected the error to be linked to the trace %v", backendErr.Contexts["trace"])
+	}
+}
@@ -0,0 +1,49 @@
+package sentry
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryTraceTransport is an http.RoundTripper that records the outgoing request as an "http.client" child span
+// of the active span of the request context, and propagates the trace and its dynamic sampling context
+// in the sentry-trace and baggage headers. Requests without an active span are sent unchanged.
+type SentryTraceTransport struct {
+	RT http.RoundTripper
+}
+
+func NewSentryTraceTransport(rt http.RoundTripper) SentryTraceTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return SentryTraceTransport{RT: rt}
+}
+
+func (stt SentryTraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		// no transaction: the headers would start tracing of requests that are not sampled here
+		return stt.RT.RoundTrip(req)
+	}
+
+	url := SanitizeURL(req.URL)
+	span := parent.StartChild("http.client", sentry.WithDescription(req.Method+" "+url))
+	defer span.Finish()
+	span.SetTag("http.method", req.Method)
+	span.SetTag("http.url", url)
+
+	req = injectBaggage(req.WithContext(span.Context()), span.ToBaggage())
+	req.Header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+
+	resp, err := stt.RT.RoundTrip(req)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return resp, err
+	}
+	span.SetTag("http.status_code", strconv.Itoa(resp.StatusCode))
+	span.Status = sentry.HTTPtoSpanStatus(resp.StatusCode)
+	return resp, err
+}
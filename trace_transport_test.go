@@ -0,0 +1,126 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func newTracingHub(t *testing.T) (*sentry.Hub, *sentrytest.EventRecorder) {
+	t.Helper()
+	transport := &sentrytest.EventRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              "https://key@o1.ingest.sentry.io/1",
+		Transport:        transport,
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), transport
+}
+
+func TestSentryTraceTransportSpan(t *testing.T) {
+	var traceHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get(sentry.SentryTraceHeader)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	hub, transport := newTracingHub(t)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	tx := sentry.StartTransaction(ctx, "job")
+	req, err := http.NewRequestWithContext(tx.Context(), http.MethodGet, ts.URL+"/items/7?key=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: NewSentryTraceTransport(nil)}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	tx.Finish()
+
+	if !strings.HasPrefix(traceHeader, tx.TraceID.String()) {
+		t.Errorf("trace not propagated %q", traceHeader)
+	}
+	if len(transport.Events()) != 1 || len(transport.Events()[0].Spans) != 1 {
+		t.Fatalf("expected a transaction with 1 span %v", transport.Events())
+	}
+	span := transport.Events()[0].Spans[0]
+	if span.Op != "http.client" || span.Description != "GET "+ts.URL+"/items/7" {
+		t.Errorf("unexpected span %s %s", span.Op, span.Description)
+	}
+	if span.Tags["http.status_code"] != "404" || span.Status != sentry.SpanStatusNotFound {
+		t.Errorf("unexpected span status %v %v", span.Tags, span.Status)
+	}
+}
+
+func TestSentryTraceTransportNoTransaction(t *testing.T) {
+	var headers http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	hub, transport := newTracingHub(t)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&http.Client{Transport: NewSentryTraceTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	hub.Flush(0)
+	if len(transport.Events()) != 0 {
+		t.Errorf("expected no span to be recorded %v", transport.Events())
+	}
+	if headers.Get(sentry.SentryTraceHeader) != "" || headers.Get(sentry.SentryBaggageHeader) != "" {
+		t.Errorf("expected no trace headers %v", headers)
+	}
+}
+
+func TestSentryTraceTransportDynamicSamplingContext(t *testing.T) {
+	var headers http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	hub, _ := newTracingHub(t)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	tx := sentry.StartTransaction(ctx, "GET /orders")
+	defer tx.Finish()
+	span := tx.StartChild("db.query")
+	defer span.Finish()
+
+	req, err := http.NewRequestWithContext(span.Context(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&http.Client{Transport: NewSentryTraceTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if trace := headers.Get(sentry.SentryTraceHeader); !strings.HasPrefix(trace, tx.TraceID.String()+"-") || !strings.HasSuffix(trace, "-1") {
+		t.Errorf("unexpected sentry-trace %q", trace)
+	}
+	baggage := ParseBaggage(headers.Get(sentry.SentryBaggageHeader))
+	if baggage["sentry-trace_id"] != tx.TraceID.String() || baggage["sentry-sampled"] != "true" ||
+		baggage["sentry-transaction"] != "GET /orders" {
+		t.Errorf("unexpected baggage %v", baggage)
+	}
+}
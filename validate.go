@@ -0,0 +1,102 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ErrInvalidOption is wrapped by the errors of the option validations.
+var ErrInvalidOption = errors.New("invalid option")
+
+var sentryLevels = []sentry.Level{sentry.LevelDebug, sentry.LevelInfo, sentry.LevelWarning, sentry.LevelError, sentry.LevelFatal}
+
+// ValidateFingerprintOpts reports fingerprinters without an ErrHandler, a fingerprinter error would then panic.
+// Dynamic options are resolved when the event is sent and are not validated.
+func ValidateFingerprintOpts(field string, opts FingerprintOpts) []error {
+	var errs []error
+	if len(opts.Fingerprinters) > 0 && opts.ErrHandler == nil {
+		errs = append(errs, fmt.Errorf("%w: %s.ErrHandler must be set when there are Fingerprinters", ErrInvalidOption, field))
+	}
+	for i, fingerprinter := range opts.Fingerprinters {
+		if fingerprinter == nil {
+			errs = append(errs, fmt.Errorf("%w: %s.Fingerprinters[%d] is nil", ErrInvalidOption, field, i))
+		}
+	}
+	return errs
+}
+
+// ValidateLevel reports a level that is neither empty nor a Sentry level.
+func ValidateLevel(field string, level sentry.Level) error {
+	if level == "" || slices.Contains(sentryLevels, level) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not a Sentry level, got %q", ErrInvalidOption, field, level)
+}
+
+// ValidateRedactPatterns reports patterns without a Regex.
+func ValidateRedactPatterns(field string, patterns []RedactPattern) []error {
+	var errs []error
+	for i, pattern := range patterns {
+		if pattern.Regex == nil {
+			errs = append(errs, fmt.Errorf("%w: %s[%d].Regex is nil", ErrInvalidOption, field, i))
+		}
+	}
+	return errs
+}
+
+// ValidateStatusCodes reports codes outside of 100-599.
+func ValidateStatusCodes(field string, codes []int) []error {
+	var errs []error
+	for _, code := range codes {
+		if code < 100 || code > 599 {
+			errs = append(errs, fmt.Errorf("%w: %s has an invalid status code %d", ErrInvalidOption, field, code))
+		}
+	}
+	return errs
+}
+
+// FormatOptionErrors lists the validation errors, one per line, for the panic of a middleware constructor.
+func FormatOptionErrors(name string, errs []error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d invalid options", name, len(errs))
+	for _, err := range errs {
+		b.WriteString("\n- ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// ValidateSentry500Options returns all the problems of the Middleware500 options, so that they can be fixed at once.
+func ValidateSentry500Options(opts Sentry500Options) []error {
+	var errs []error
+	if opts.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: MaxBodyBytes must not be negative, got %d", ErrInvalidOption, opts.MaxBodyBytes))
+	}
+	if opts.MaxAttachmentBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: MaxAttachmentBytes must not be negative, got %d", ErrInvalidOption, opts.MaxAttachmentBytes))
+	}
+	errs = append(errs, ValidateStatusCodes("CaptureAsMessage", opts.CaptureAsMessage)...)
+	for method, level := range opts.MethodLevelMap {
+		if err := ValidateLevel("MethodLevelMap["+method+"]", level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := ValidateLevel("DefaultLevel", opts.DefaultLevel); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, ValidateRedactPatterns("BodyRedactPatterns", opts.BodyRedactPatterns)...)
+	if slow := opts.SlowRequestCapture; slow != nil {
+		if slow.Threshold <= 0 {
+			errs = append(errs, fmt.Errorf("%w: SlowRequestCapture.Threshold must be positive, got %s", ErrInvalidOption, slow.Threshold))
+		}
+		if err := ValidateLevel("SlowRequestCapture.Level", slow.Level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, ValidateFingerprintOpts("FingerprintOpts", opts.FingerprintOpts)...)
+	return errs
+}
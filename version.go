@@ -0,0 +1,7 @@
+package sentry
+
+// Version is the version of this module, update it with each release.
+const Version = "0.1.0"
+
+// SDKName identifies the events of the middlewares in Sentry, see FingerprintOpts.CustomSDKName.
+const SDKName = "go-sentry-middleware"
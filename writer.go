@@ -0,0 +1,34 @@
+package sentry
+
+import (
+	"io"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// MaxMessageBytes caps the message of an event created by SentryWriter.
+var MaxMessageBytes = 8 * 1024
+
+type sentryWriter struct {
+	hub   *sentry.Hub
+	level sentry.Level
+}
+
+// SentryWriter returns an io.Writer that sends every Write as a Sentry event message.
+// Messages longer than MaxMessageBytes are truncated.
+func SentryWriter(hub *sentry.Hub, level sentry.Level) io.Writer {
+	return sentryWriter{hub: hub, level: level}
+}
+
+func (sw sentryWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSpace(ResponseBodyForSentry(p, "", MaxMessageBytes, false))
+	if message == "" {
+		return len(p), nil
+	}
+	event := sentry.NewEvent()
+	event.Level = sw.level
+	event.Message = message
+	sw.hub.CaptureEvent(event)
+	return len(p), nil
+}
@@ -0,0 +1,29 @@
+package sentry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/digitalmint/go-sentry-middleware/sentrytest"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestSentryWriter(t *testing.T) {
+	hub, transport := sentrytest.NewRecordingHub(t)
+	w := SentryWriter(hub, sentry.LevelError)
+	if n, err := w.Write([]byte("panic: boom\n")); err != nil || n != 12 {
+		t.Fatalf("unexpected %d %v", n, err)
+	}
+	if _, err := w.Write([]byte(strings.Repeat("x", MaxMessageBytes+10))); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.Events()) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.Events()))
+	}
+	if event := transport.Events()[0]; event.Message != "panic: boom" || event.Level != sentry.LevelError {
+		t.Errorf("unexpected event %s %s", event.Message, event.Level)
+	}
+	if len(transport.Events()[1].Message) != MaxMessageBytes {
+		t.Errorf("expected message to be capped, got %d bytes", len(transport.Events()[1].Message))
+	}
+}